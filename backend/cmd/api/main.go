@@ -8,12 +8,14 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"saas-core-template/backend/internal/analytics"
 	"saas-core-template/backend/internal/api"
+	"saas-core-template/backend/internal/apikeys"
 	"saas-core-template/backend/internal/audit"
 	"saas-core-template/backend/internal/auth"
 	"saas-core-template/backend/internal/billing"
@@ -91,6 +93,10 @@ func main() {
 	}
 	defer pool.Close()
 
+	if cfg.RedisURL == "" {
+		slog.Error("REDIS_URL is required")
+		os.Exit(1)
+	}
 	redisClient, err := cache.Connect(ctx, cfg.RedisURL)
 	if err != nil {
 		slog.Error("failed to connect to redis", "error", err)
@@ -103,6 +109,7 @@ func main() {
 	auditRecorder := audit.NewDBRecorder(pool)
 	jobStore := jobs.NewStore(pool)
 	orgService := orgs.NewService(pool, orgs.WithJobs(jobStore), orgs.WithAudit(auditRecorder))
+	apiKeyService := apikeys.NewService(pool, apikeys.WithAudit(auditRecorder))
 
 	var s3Provider *files.S3Provider
 	if cfg.FileStorageProvider == "s3" {
@@ -133,10 +140,19 @@ func main() {
 		})
 	}
 
+	authProvider, err := buildAuthProvider(cfg)
+	if err != nil {
+		slog.Error("failed to initialize auth provider", "error", err)
+		os.Exit(1)
+	}
+
 	var authService *auth.Service
-	if cfg.ClerkSecretKey != "" {
-		authProvider := auth.NewClerkProvider(cfg.ClerkSecretKey, cfg.ClerkAPIURL)
-		authService = auth.NewService(authProvider, pool, auth.WithJobs(jobStore), auth.WithAudit(auditRecorder))
+	if authProvider != nil {
+		authOpts := []func(*auth.Service){auth.WithJobs(jobStore), auth.WithAudit(auditRecorder)}
+		if cfg.AuthSessionCacheTTL > 0 {
+			authOpts = append(authOpts, auth.WithSessionCache(cache.NewStore(redisClient, "auth:session:"), cfg.AuthSessionCacheTTL))
+		}
+		authService = auth.NewService(authProvider, pool, authOpts...)
 	}
 
 	var billingService *billing.Service
@@ -164,6 +180,7 @@ func main() {
 		api.WithAudit(auditRecorder),
 		api.WithFiles(filesService),
 		api.WithOrgs(orgService),
+		api.WithAPIKeys(apiKeyService),
 	)
 
 	baseHandler := apiServer.Handler()
@@ -204,3 +221,73 @@ func waitForShutdown(server *http.Server) {
 
 	slog.Info("server stopped")
 }
+
+// buildAuthProvider returns the provider named by AUTH_PROVIDER, or a chain of
+// the providers listed in AUTH_PROVIDERS (tried in order) when that is set.
+func buildAuthProvider(cfg config.Config) (auth.Provider, error) {
+	names := []string{cfg.AuthProvider}
+	if strings.TrimSpace(cfg.AuthProviders) != "" {
+		names = strings.Split(cfg.AuthProviders, ",")
+	}
+
+	var providers []auth.Provider
+	for _, name := range names {
+		provider, err := newAuthProvider(strings.ToLower(strings.TrimSpace(name)), cfg)
+		if err != nil {
+			return nil, err
+		}
+		if provider != nil {
+			providers = append(providers, provider)
+		}
+	}
+
+	switch len(providers) {
+	case 0:
+		return nil, nil
+	case 1:
+		return providers[0], nil
+	default:
+		return auth.NewChainProvider(providers...), nil
+	}
+}
+
+func newAuthProvider(name string, cfg config.Config) (auth.Provider, error) {
+	switch name {
+	case "", "none", "noop", "off", "disabled":
+		return nil, nil
+	case "dev":
+		// A defaulted APP_ENV must not enable dev auth.
+		env := ""
+		if cfg.EnvSet {
+			env = cfg.Env
+		}
+		p, err := auth.NewDevProvider(auth.DevConfig{Env: env, Secret: cfg.AuthDevSecret, AllowPlainTokens: cfg.AuthDevPlainTokens})
+		if err != nil {
+			return nil, err
+		}
+		slog.Warn("dev auth provider enabled; do not use outside local development and tests")
+		return p, nil
+	case "oidc":
+		p, err := auth.NewOIDCProvider(auth.OIDCConfig{
+			Issuer:       cfg.OIDCIssuer,
+			Audience:     cfg.OIDCAudience,
+			JWKSURL:      cfg.OIDCJWKSURL,
+			ClockSkew:    cfg.OIDCClockSkew,
+			ProviderName: cfg.OIDCProviderName,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return p, nil
+	case "clerk":
+		if cfg.ClerkSecretKey == "" {
+			return nil, nil
+		}
+		return auth.NewClerkProvider(cfg.ClerkSecretKey, cfg.ClerkAPIURL,
+			auth.WithClerkIssuer(cfg.ClerkIssuer),
+			auth.WithAuthorizedParties(strings.Split(cfg.ClerkAuthorizedParties, ",")...),
+		), nil
+	default:
+		return nil, fmt.Errorf("unknown auth provider %q", name)
+	}
+}
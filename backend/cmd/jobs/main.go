@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"saas-core-template/backend/internal/db"
+	"saas-core-template/backend/internal/jobs"
+)
+
+func main() {
+	ctx := context.Background()
+
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd := strings.TrimSpace(os.Args[1])
+	switch cmd {
+	case "list":
+		fs := flag.NewFlagSet("list", flag.ExitOnError)
+		status := fs.String("status", "", "filter by status (queued|processing|done|failed|cancelled)")
+		jobType := fs.String("type", "", "filter by job type")
+		queue := fs.String("queue", "", "filter by queue")
+		batch := fs.String("batch", "", "filter by batch ID")
+		limit := fs.Int("limit", 50, "maximum number of jobs to show")
+		_ = fs.Parse(os.Args[2:])
+
+		if *status != "" && !jobs.ValidStatus(*status) {
+			fatalf("unknown status %q", *status)
+		}
+
+		store := connect(ctx)
+		records, err := store.List(ctx, jobs.ListFilter{Status: *status, Type: *jobType, Queue: *queue, BatchID: *batch, Limit: *limit})
+		if err != nil {
+			fatalf("list: %v", err)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tTYPE\tQUEUE\tPRIORITY\tSTATUS\tATTEMPTS\tRUN AT\tUPDATED AT\tLAST ERROR")
+		for _, rec := range records {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%d/%d\t%s\t%s\t%s\n",
+				rec.ID,
+				rec.Type,
+				rec.Queue,
+				rec.Priority,
+				rec.Status,
+				rec.Attempts,
+				rec.MaxAttempts,
+				rec.RunAt.UTC().Format(time.RFC3339),
+				rec.UpdatedAt.UTC().Format(time.RFC3339),
+				truncate(rec.LastError, 60),
+			)
+		}
+		_ = w.Flush()
+		fmt.Printf("%d job(s)\n", len(records))
+	case "show":
+		fs := flag.NewFlagSet("show", flag.ExitOnError)
+		_ = fs.Parse(os.Args[2:])
+		if fs.NArg() != 1 {
+			fatalf("usage: show <job-id>")
+		}
+
+		store := connect(ctx)
+		rec, err := store.Get(ctx, fs.Arg(0))
+		if errors.Is(err, jobs.ErrNotFound) {
+			fatalf("job %s not found", fs.Arg(0))
+		}
+		if err != nil {
+			fatalf("show: %v", err)
+		}
+
+		attempts, err := store.ListAttempts(ctx, rec.ID)
+		if err != nil {
+			fatalf("show attempts: %v", err)
+		}
+
+		printJSON(map[string]any{"job": rec, "attempts": attempts})
+	case "retry":
+		fs := flag.NewFlagSet("retry", flag.ExitOnError)
+		sel := selectorFlags(fs)
+		_ = fs.Parse(os.Args[2:])
+
+		store := connect(ctx)
+		res, err := store.RetryFailed(ctx, sel.selector(fs))
+		if err != nil {
+			fatalf("retry: %v", err)
+		}
+		fmt.Printf("requeued %d failed job(s)\n", res.Retried)
+		if res.Skipped > 0 {
+			fmt.Printf("skipped %d failed job(s) whose unique key is held by an active job\n", res.Skipped)
+		}
+	case "cancel":
+		fs := flag.NewFlagSet("cancel", flag.ExitOnError)
+		sel := selectorFlags(fs)
+		_ = fs.Parse(os.Args[2:])
+
+		store := connect(ctx)
+		count, err := store.CancelQueued(ctx, sel.selector(fs))
+		if err != nil {
+			fatalf("cancel: %v", err)
+		}
+		fmt.Printf("cancelled %d queued job(s)\n", count)
+	case "batch":
+		fs := flag.NewFlagSet("batch", flag.ExitOnError)
+		_ = fs.Parse(os.Args[2:])
+		if fs.NArg() != 1 {
+			fatalf("usage: batch <batch-id>")
+		}
+
+		store := connect(ctx)
+		batch, err := store.GetBatch(ctx, fs.Arg(0))
+		if errors.Is(err, jobs.ErrBatchNotFound) {
+			fatalf("batch %s not found", fs.Arg(0))
+		}
+		if err != nil {
+			fatalf("batch: %v", err)
+		}
+		printJSON(batch)
+	case "stats":
+		store := connect(ctx)
+		stats, err := store.Stats(ctx)
+		if err != nil {
+			fatalf("stats: %v", err)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TYPE\tQUEUED\tPROCESSING\tFAILED\tOLDEST QUEUED")
+		for _, ts := range stats.Types {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%s\n", ts.Type, ts.Queued, ts.Processing, ts.Failed, ts.OldestQueuedAge.Round(time.Second))
+		}
+		fmt.Fprintf(w, "(total)\t%d\t%d\t%d\t%s\n", stats.Queued, stats.Processing, stats.Failed, stats.OldestQueuedAge.Round(time.Second))
+		_ = w.Flush()
+	case "purge":
+		fs := flag.NewFlagSet("purge", flag.ExitOnError)
+		status := fs.String("status", jobs.StatusDone, "status to purge (done|failed|cancelled)")
+		olderThan := fs.Duration("older-than", 7*24*time.Hour, "only purge jobs last updated before this age")
+		_ = fs.Parse(os.Args[2:])
+
+		store := connect(ctx)
+		count, err := store.Purge(ctx, *status, *olderThan)
+		if err != nil {
+			fatalf("purge: %v", err)
+		}
+		fmt.Printf("purged %d %s job(s) older than %s\n", count, *status, olderThan.String())
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage:")
+	fmt.Fprintln(os.Stderr, "  go run ./cmd/jobs list [-status failed] [-type send_email] [-queue default] [-batch <batch-id>] [-limit 50]")
+	fmt.Fprintln(os.Stderr, "  go run ./cmd/jobs show <job-id>")
+	fmt.Fprintln(os.Stderr, "  go run ./cmd/jobs retry (-all | -type send_email | <job-id>...)")
+	fmt.Fprintln(os.Stderr, "  go run ./cmd/jobs cancel (-all | -type send_email | <job-id>...)")
+	fmt.Fprintln(os.Stderr, "  go run ./cmd/jobs batch <batch-id>")
+	fmt.Fprintln(os.Stderr, "  go run ./cmd/jobs stats")
+	fmt.Fprintln(os.Stderr, "  go run ./cmd/jobs purge [-status done] [-older-than 168h]")
+}
+
+func fatalf(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
+
+func connect(ctx context.Context) *jobs.Store {
+	databaseURL := strings.TrimSpace(os.Getenv("DATABASE_URL"))
+	if databaseURL == "" {
+		fatalf("DATABASE_URL is required")
+	}
+
+	pool, err := db.Connect(ctx, databaseURL)
+	if err != nil {
+		fatalf("connect: %v", err)
+	}
+	return jobs.NewStore(pool)
+}
+
+type selectorFlagValues struct {
+	all     *bool
+	jobType *string
+}
+
+func selectorFlags(fs *flag.FlagSet) selectorFlagValues {
+	return selectorFlagValues{
+		all:     fs.Bool("all", false, "apply to every matching job"),
+		jobType: fs.String("type", "", "only jobs of this type"),
+	}
+}
+
+// selector builds a jobs.Selector from flags plus job IDs given as positional arguments.
+func (v selectorFlagValues) selector(fs *flag.FlagSet) jobs.Selector {
+	return jobs.Selector{
+		IDs:  fs.Args(),
+		Type: *v.jobType,
+		All:  *v.all,
+	}
+}
+
+func printJSON(value any) {
+	encoded, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		fatalf("encode: %v", err)
+	}
+	fmt.Println(string(encoded))
+}
+
+func truncate(value string, max int) string {
+	value = strings.ReplaceAll(value, "\n", " ")
+	if len(value) <= max {
+		return value
+	}
+	return value[:max-3] + "..."
+}
@@ -2,19 +2,22 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
-	"fmt"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+	"saas-core-template/backend/internal/cache"
 	"saas-core-template/backend/internal/config"
 	"saas-core-template/backend/internal/db"
 	"saas-core-template/backend/internal/email"
 	"saas-core-template/backend/internal/errorreporting"
+	"saas-core-template/backend/internal/files"
 	"saas-core-template/backend/internal/jobs"
 	"saas-core-template/backend/internal/telemetry"
 )
@@ -69,71 +72,192 @@ func main() {
 	}
 	defer pool.Close()
 
-	sender := buildEmailSender(cfg)
+	filesService, err := buildFilesService(ctx, cfg, pool)
+	if err != nil {
+		slog.Error("failed to initialize files service", "error", err)
+		os.Exit(1)
+	}
+
+	registry := jobs.NewRegistry()
+	email.RegisterJobs(registry, buildEmailSender(cfg), defaultString(cfg.EmailFrom, "local@example.com"))
+	// Deleting abandoned uploads is destructive, so it only runs when enabled.
+	if filesService != nil && cfg.FileCleanupPending {
+		files.RegisterJobs(registry, filesService)
+	}
+	jobs.RegisterPruneJob(registry, jobs.NewStore(pool))
+
+	rateLimits := registry.RateLimits()
+	rateLimitOverrides, err := jobs.ParseRateLimits(cfg.JobsRateLimits)
+	if err != nil {
+		slog.Error("failed to parse JOBS_RATE_LIMITS", "error", err)
+		os.Exit(1)
+	}
+	for jobType, limit := range rateLimitOverrides {
+		rateLimits[jobType] = limit
+	}
+
+	// Redis is only needed to share rate limits across workers. Without it the
+	// worker refuses to start unless JOBS_RATE_LIMIT_FAIL_OPEN allows running
+	// the limited types unthrottled.
+	var rateLimiter jobs.RateLimiter
+	if len(rateLimits) > 0 {
+		redisClient, err := connectRedis(ctx, cfg.RedisURL)
+		switch {
+		case err != nil && cfg.JobsRateLimitFailOpen:
+			slog.Warn("redis unavailable; running without job rate limits", "error", err)
+		case err != nil:
+			slog.Error("job rate limits need redis", "error", err)
+			os.Exit(1)
+		default:
+			defer func() { _ = redisClient.Close() }()
+			rateLimiter = cache.NewRateLimiter(redisClient, "ratelimit:")
+		}
+	}
+
 	claimer := jobs.NewClaimer(pool, jobs.ClaimerConfig{
-		WorkerID: cfg.JobsWorkerID,
-		LockTTL:  5 * time.Minute,
+		WorkerID:          cfg.JobsWorkerID,
+		LockTTL:           5 * time.Minute,
+		RateLimiter:       rateLimiter,
+		RateLimits:        rateLimits,
+		RateLimitFailOpen: cfg.JobsRateLimitFailOpen,
 	})
 
-	slog.Info("worker started", "name", workerName, "worker_id", cfg.JobsWorkerID, "poll", cfg.JobsPollInterval.String())
+	typeConcurrency, err := jobs.ParseLimits(cfg.JobsTypeConcurrency)
+	if err != nil {
+		slog.Error("failed to parse JOBS_TYPE_CONCURRENCY", "error", err)
+		os.Exit(1)
+	}
+
+	queues, err := jobs.ParseLimits(cfg.JobsQueues)
+	if err != nil {
+		slog.Error("failed to parse JOBS_QUEUES", "error", err)
+		os.Exit(1)
+	}
+
+	worker := jobs.NewWorker(claimer, registry, jobs.WorkerConfig{
+		Concurrency:     cfg.JobsConcurrency,
+		TypeConcurrency: typeConcurrency,
+		Queues:          queues,
+		PollInterval:    cfg.JobsPollInterval,
+		ReapInterval:    cfg.JobsReapInterval,
+		ShutdownTimeout: cfg.JobsShutdownTimeout,
+		OnError: func(err error) {
+			errorreporting.Capture(context.Background(), reporter, err, map[string]string{"component": "worker"})
+		},
+	})
 
-	ticker := time.NewTicker(cfg.JobsPollInterval)
-	defer ticker.Stop()
+	if cfg.JobsListenEnabled {
+		go jobs.NewListener(pool, worker.Wake).Run(ctx)
+	}
 
-	from := defaultString(cfg.EmailFrom, "local@example.com")
+	if cfg.JobsSchedulerEnabled {
+		scheduler := jobs.NewScheduler(pool, jobs.NewStore(pool), jobs.SchedulerConfig{
+			OnError: func(err error) {
+				errorreporting.Capture(context.Background(), reporter, err, map[string]string{"component": "scheduler"})
+			},
+		})
+		if err := registerSchedules(scheduler, registry, cfg); err != nil {
+			slog.Error("failed to register schedules", "error", err)
+			os.Exit(1)
+		}
+		go scheduler.Run(ctx)
+	}
 
-	for {
-		select {
-		case <-ctx.Done():
-			slog.Info("worker shutting down")
-			return
-		case <-ticker.C:
-			if err := runOnce(ctx, claimer, sender, from); err != nil && !errors.Is(err, context.Canceled) {
-				errorreporting.Capture(ctx, reporter, err, map[string]string{"component": "worker"})
-			}
+	if cfg.JobsHTTPAddr != "" {
+		healthServer := &http.Server{
+			Addr:              cfg.JobsHTTPAddr,
+			Handler:           jobs.NewHealthHandler(pool, jobs.NewStore(pool), worker).Handler(),
+			ReadHeaderTimeout: 5 * time.Second,
 		}
+		go func() {
+			slog.Info("worker health server started", "addr", healthServer.Addr)
+			if err := healthServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				slog.Error("worker health server terminated unexpectedly", "error", err)
+			}
+		}()
+		defer func() {
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_ = healthServer.Shutdown(shutdownCtx)
+		}()
 	}
+
+	slog.Info("worker started",
+		"name", workerName,
+		"worker_id", cfg.JobsWorkerID,
+		"poll", cfg.JobsPollInterval.String(),
+		"concurrency", cfg.JobsConcurrency,
+		"queues", cfg.JobsQueues,
+		"listen", cfg.JobsListenEnabled,
+		"job_types", registry.Types(),
+	)
+
+	worker.Run(ctx)
+	slog.Info("worker stopped")
 }
 
-func runOnce(ctx context.Context, claimer *jobs.Claimer, sender email.Sender, from string) error {
-	job, err := claimer.ClaimNext(ctx)
-	if err != nil {
-		return err
-	}
-	if job == nil {
-		return nil
+// registerSchedules declares recurring jobs. Schedules whose job type has no
+// handler in this worker are skipped.
+func registerSchedules(scheduler *jobs.Scheduler, registry *jobs.Registry, cfg config.Config) error {
+	schedules := []struct {
+		name    string
+		spec    string
+		jobType string
+		payload any
+	}{
+		{name: "files_cleanup_pending", spec: "@hourly", jobType: files.CleanupPendingJobType, payload: files.CleanupPendingJob{OlderThanHours: 24}},
+		{name: "jobs_prune", spec: "@hourly", jobType: jobs.PruneJobType, payload: jobs.PruneJob{
+			DoneRetentionDays:   cfg.JobsDoneRetentionDays,
+			FailedRetentionDays: cfg.JobsFailedRetentionDays,
+			Archive:             cfg.JobsArchiveEnabled,
+		}},
 	}
 
-	switch job.Type {
-	case "send_email":
-		var payload struct {
-			To      string `json:"to"`
-			Subject string `json:"subject"`
-			Text    string `json:"text"`
-			HTML    string `json:"html"`
+	for _, sched := range schedules {
+		if _, ok := registry.Options(sched.jobType); !ok {
+			continue
 		}
-		if err := json.Unmarshal(job.PayloadJSON, &payload); err != nil {
-			_ = claimer.Fail(ctx, jobs.FailureInput{JobID: job.ID, Attempts: job.Attempts, MaxAttempts: job.MaxAttempts, Err: fmt.Errorf("decode payload: %w", err)})
-			return nil
+		if err := scheduler.Add(sched.name, sched.spec, sched.jobType, sched.payload); err != nil {
+			return err
 		}
+	}
+	return nil
+}
 
-		msg := email.Message{
-			To:      payload.To,
-			From:    from,
-			Subject: payload.Subject,
-			Text:    payload.Text,
-			HTML:    payload.HTML,
-		}
+func connectRedis(ctx context.Context, redisURL string) (*redis.Client, error) {
+	if redisURL == "" {
+		return nil, errors.New("REDIS_URL is not set")
+	}
+	return cache.Connect(ctx, redisURL)
+}
 
-		if err := sender.Send(ctx, msg); err != nil {
-			_ = claimer.Fail(ctx, jobs.FailureInput{JobID: job.ID, Attempts: job.Attempts, MaxAttempts: job.MaxAttempts, Err: err})
-			return nil
+func buildFilesService(ctx context.Context, cfg config.Config, pool *pgxpool.Pool) (*files.Service, error) {
+	switch cfg.FileStorageProvider {
+	case "none", "noop", "off", "disabled":
+		return nil, nil
+	}
+
+	var s3Provider *files.S3Provider
+	if cfg.FileStorageProvider == "s3" {
+		p, err := files.NewS3Provider(ctx, files.S3Config{
+			Bucket:          cfg.S3Bucket,
+			Region:          cfg.S3Region,
+			Endpoint:        cfg.S3Endpoint,
+			AccessKeyID:     cfg.S3AccessKeyID,
+			SecretAccessKey: cfg.S3SecretAccessKey,
+			ForcePathStyle:  cfg.S3ForcePathStyle,
+		})
+		if err != nil {
+			return nil, err
 		}
-		return claimer.Complete(ctx, job.ID)
-	default:
-		_ = claimer.Fail(ctx, jobs.FailureInput{JobID: job.ID, Attempts: job.Attempts, MaxAttempts: job.MaxAttempts, Err: fmt.Errorf("unknown job type %q", job.Type)})
-		return nil
+		s3Provider = p
 	}
+
+	return files.NewService(pool, files.Config{
+		Provider: cfg.FileStorageProvider,
+		DiskPath: cfg.FileStorageDiskPath,
+		S3:       s3Provider,
+	}), nil
 }
 
 func buildEmailSender(cfg config.Config) email.Sender {
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"saas-core-template/backend/internal/apikeys"
+)
+
+func (s *Server) apiKeysList(w http.ResponseWriter, r *http.Request) {
+	if s.apiKeys == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "api_keys_not_configured"})
+		return
+	}
+
+	org := authOrgFromContext(r.Context())
+	if org.ID == "" {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "organization_required"})
+		return
+	}
+
+	keys, err := s.apiKeys.List(r.Context(), org.ID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed_to_list_api_keys"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"apiKeys": keys})
+}
+
+func (s *Server) apiKeysCreate(w http.ResponseWriter, r *http.Request) {
+	if s.apiKeys == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "api_keys_not_configured"})
+		return
+	}
+
+	// denyAPIKeys keeps keys out; a PAT also resolves to a user, so refuse it
+	// here. Organization keys are only issued from an interactive session.
+	user := authUserFromContext(r.Context())
+	org := authOrgFromContext(r.Context())
+	if user.ID == "" || patFromContext(r.Context()).ID != "" {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "user_session_required"})
+		return
+	}
+	if org.ID == "" {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "organization_required"})
+		return
+	}
+
+	var req struct {
+		Name      string     `json:"name"`
+		Scopes    []string   `json:"scopes"`
+		ExpiresAt *time.Time `json:"expiresAt"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request_body"})
+		return
+	}
+
+	key, secret, err := s.apiKeys.Create(r.Context(), apikeys.CreateInput{
+		OrganizationID:  org.ID,
+		CreatedByUserID: user.ID,
+		Name:            req.Name,
+		Scopes:          req.Scopes,
+		ExpiresAt:       req.ExpiresAt,
+	})
+	if err != nil {
+		if errors.Is(err, apikeys.ErrInvalidScope) {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_scope"})
+			return
+		}
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "failed_to_create_api_key"})
+		return
+	}
+
+	// Only the key's hash is stored; the caller must save the secret now.
+	writeJSON(w, http.StatusOK, map[string]any{
+		"apiKey": key,
+		"secret": secret,
+	})
+}
+
+func (s *Server) apiKeysRevoke(w http.ResponseWriter, r *http.Request) {
+	if s.apiKeys == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "api_keys_not_configured"})
+		return
+	}
+
+	user := authUserFromContext(r.Context())
+	org := authOrgFromContext(r.Context())
+	if user.ID == "" {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "user_session_required"})
+		return
+	}
+	if org.ID == "" {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "organization_required"})
+		return
+	}
+
+	keyID := strings.TrimSpace(r.PathValue("id"))
+	if keyID == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "missing_api_key_id"})
+		return
+	}
+
+	if err := s.apiKeys.Revoke(r.Context(), org.ID, keyID, user.ID); err != nil {
+		if errors.Is(err, apikeys.ErrNotFound) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "api_key_not_found"})
+			return
+		}
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed_to_revoke_api_key"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"status": "revoked"})
+}
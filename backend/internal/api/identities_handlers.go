@@ -0,0 +1,88 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"saas-core-template/backend/internal/auth"
+)
+
+func (s *Server) authIdentitiesList(w http.ResponseWriter, r *http.Request) {
+	user := authUserFromContext(r.Context())
+	if user.ID == "" {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "user_not_found"})
+		return
+	}
+
+	identities, err := s.auth.ListIdentities(r.Context(), user.ID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed_to_list_identities"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"identities": identities})
+}
+
+func (s *Server) authIdentitiesLink(w http.ResponseWriter, r *http.Request) {
+	user := authUserFromContext(r.Context())
+	if user.ID == "" || patFromContext(r.Context()).ID != "" {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "user_session_required"})
+		return
+	}
+
+	// Token is a session token from the provider being linked; the request
+	// itself is authenticated with the user's current session.
+	var req struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.Token) == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request_body"})
+		return
+	}
+	if auth.IsPersonalAccessToken(strings.TrimSpace(req.Token)) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_identity_token"})
+		return
+	}
+
+	identity, err := s.auth.LinkIdentity(r.Context(), user.ID, strings.TrimSpace(req.Token))
+	if err != nil {
+		if errors.Is(err, auth.ErrIdentityLinkedElsewhere) {
+			writeJSON(w, http.StatusConflict, map[string]string{"error": "identity_linked_to_another_user"})
+			return
+		}
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "failed_to_link_identity"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"identity": identity})
+}
+
+func (s *Server) authIdentitiesUnlink(w http.ResponseWriter, r *http.Request) {
+	user := authUserFromContext(r.Context())
+	if user.ID == "" || patFromContext(r.Context()).ID != "" {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "user_session_required"})
+		return
+	}
+
+	identityID := strings.TrimSpace(r.PathValue("id"))
+	if identityID == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "missing_identity_id"})
+		return
+	}
+
+	if err := s.auth.UnlinkIdentity(r.Context(), user.ID, identityID); err != nil {
+		switch {
+		case errors.Is(err, auth.ErrIdentityNotFound):
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "identity_not_found"})
+		case errors.Is(err, auth.ErrLastIdentity):
+			writeJSON(w, http.StatusConflict, map[string]string{"error": "cannot_unlink_last_identity"})
+		default:
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed_to_unlink_identity"})
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"status": "unlinked"})
+}
@@ -118,11 +118,13 @@ func (s *Server) orgInvitesCreate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	invitePageURL := strings.TrimRight(s.appBaseURL, "/") + "/app/invite"
 	invite, err := s.orgs.CreateInvite(r.Context(), orgs.CreateInviteInput{
 		OrganizationID:  org.ID,
 		InvitedByUserID: user.ID,
 		Email:           req.Email,
 		Role:            req.Role,
+		AcceptURL:       invitePageURL,
 	})
 	if err != nil {
 		if errors.Is(err, orgs.ErrInviteAlreadyExists) {
@@ -137,11 +139,9 @@ func (s *Server) orgInvitesCreate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	acceptURL := strings.TrimRight(s.appBaseURL, "/") + "/app/invite?token=" + invite.Token
-	_ = s.orgs.EnqueueInviteEmail(r.Context(), invite, acceptURL)
 	writeJSON(w, http.StatusOK, map[string]any{
 		"invite":    invite,
-		"acceptUrl": acceptURL,
+		"acceptUrl": orgs.InviteAcceptURL(invitePageURL, invite.Token),
 	})
 }
 
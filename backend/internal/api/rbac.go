@@ -3,6 +3,8 @@ package api
 import (
 	"net/http"
 	"strings"
+
+	"saas-core-template/backend/internal/apikeys"
 )
 
 type orgRole string
@@ -40,6 +42,15 @@ func orgRoleAllows(actual string, required orgRole) bool {
 	return actualRank >= requiredRank
 }
 
+// patOrgRole caps the user's role for a request made with a personal access
+// token: without the admin scope a PAT acts as a member, whatever the user's role.
+func patOrgRole(role string, scopes []string) string {
+	if !apikeys.Has(scopes, apikeys.ScopeAdmin) && orgRoleAllows(role, orgRoleAdmin) {
+		return string(orgRoleMember)
+	}
+	return role
+}
+
 func (s *Server) requireOrgRole(required orgRole, next http.HandlerFunc) http.HandlerFunc {
 	return s.requireOrg(func(w http.ResponseWriter, r *http.Request) {
 		org := authOrgFromContext(r.Context())
@@ -56,3 +67,16 @@ func (s *Server) requireOrgRole(required orgRole, next http.HandlerFunc) http.Ha
 		next.ServeHTTP(w, r)
 	})
 }
+
+// denyAPIKeys closes a route to organization API keys whatever their scopes.
+// Managing members, keys and billing is left to people, so an admin key
+// leaked from an integration cannot take over the organization.
+func denyAPIKeys(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if apiKeyFromContext(r.Context()).ID != "" {
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": "api_key_not_allowed"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+}
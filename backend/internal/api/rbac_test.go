@@ -1,6 +1,14 @@
 package api
 
-import "testing"
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"saas-core-template/backend/internal/apikeys"
+	"saas-core-template/backend/internal/auth"
+)
 
 func TestOrgRoleAllows(t *testing.T) {
 	t.Run("rejects unknown actual role", func(t *testing.T) {
@@ -39,3 +47,96 @@ func TestOrgRoleAllows(t *testing.T) {
 		}
 	})
 }
+
+func TestAPIKeyScopeRoles(t *testing.T) {
+	tests := []struct {
+		name        string
+		scopes      []string
+		wantRole    string
+		allowsAdmin bool
+	}{
+		{name: "read acts as member", scopes: []string{"read"}, wantRole: "member"},
+		{name: "write acts as member", scopes: []string{"write"}, wantRole: "member"},
+		{name: "admin acts as admin", scopes: []string{"admin"}, wantRole: "admin", allowsAdmin: true},
+		{name: "admin among others acts as admin", scopes: []string{"read", "admin"}, wantRole: "admin", allowsAdmin: true},
+		{name: "no scopes act as member", scopes: nil, wantRole: "member"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			role := apikeys.OrgRole(tc.scopes)
+			if role != tc.wantRole {
+				t.Fatalf("expected role %q, got %q", tc.wantRole, role)
+			}
+			if !orgRoleAllows(role, orgRoleMember) {
+				t.Fatalf("expected role %q to meet member", role)
+			}
+			if got := orgRoleAllows(role, orgRoleAdmin); got != tc.allowsAdmin {
+				t.Fatalf("expected admin access %v, got %v", tc.allowsAdmin, got)
+			}
+		})
+	}
+}
+
+func TestPATOrgRole(t *testing.T) {
+	tests := []struct {
+		name   string
+		role   string
+		scopes []string
+		want   string
+	}{
+		{name: "owner with admin scope stays owner", role: "owner", scopes: []string{"admin"}, want: "owner"},
+		{name: "admin with admin scope stays admin", role: "admin", scopes: []string{"admin"}, want: "admin"},
+		{name: "owner with write scope is downgraded", role: "owner", scopes: []string{"write"}, want: "member"},
+		{name: "admin with read scope is downgraded", role: "admin", scopes: []string{"read"}, want: "member"},
+		{name: "admin without scopes is downgraded", role: "admin", scopes: nil, want: "member"},
+		{name: "member with admin scope stays member", role: "member", scopes: []string{"admin"}, want: "member"},
+		{name: "member with read scope stays member", role: "member", scopes: []string{"read"}, want: "member"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := patOrgRole(tc.role, tc.scopes); got != tc.want {
+				t.Fatalf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestDenyAPIKeys(t *testing.T) {
+	handler := denyAPIKeys(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/billing/portal-session", nil)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected a user request to pass, got %d", rec.Code)
+	}
+
+	ctx := context.WithValue(req.Context(), authAPIKeyContextKey, apikeys.Key{ID: "key_1", Scopes: []string{"admin"}})
+	rec = httptest.NewRecorder()
+	handler(rec, req.WithContext(ctx))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected an admin API key to be rejected, got %d", rec.Code)
+	}
+}
+
+func TestActorAttribution(t *testing.T) {
+	ctx := context.WithValue(context.Background(), authUserContextKey, auth.User{ID: "user_1"})
+	if got := actorDistinctID(ctx); got != "user_1" {
+		t.Fatalf("expected the user as distinct ID, got %q", got)
+	}
+	if data := withActorData(ctx, map[string]any{}); len(data) != 0 {
+		t.Fatalf("expected user audit data to be unchanged, got %v", data)
+	}
+
+	ctx = context.WithValue(context.Background(), authAPIKeyContextKey, apikeys.Key{ID: "key_1"})
+	if got := actorDistinctID(ctx); got != "api_key:key_1" {
+		t.Fatalf("expected the API key as distinct ID, got %q", got)
+	}
+	if data := withActorData(ctx, map[string]any{"file_id": "f"}); data["api_key_id"] != "key_1" || data["file_id"] != "f" {
+		t.Fatalf("expected the API key ID in audit data, got %v", data)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/redis/go-redis/v9"
 	"saas-core-template/backend/internal/analytics"
+	"saas-core-template/backend/internal/apikeys"
 	"saas-core-template/backend/internal/audit"
 	"saas-core-template/backend/internal/auth"
 	"saas-core-template/backend/internal/billing"
@@ -32,6 +33,7 @@ type Server struct {
 	audit      audit.Recorder
 	files      *files.Service
 	orgs       *orgs.Service
+	apiKeys    *apikeys.Service
 }
 
 type serverOptions struct {
@@ -42,6 +44,7 @@ type serverOptions struct {
 	audit          audit.Recorder
 	files          *files.Service
 	orgs           *orgs.Service
+	apiKeys        *apikeys.Service
 }
 
 func NewServer(appName string, env string, version string, db *pgxpool.Pool, redisClient *redis.Client, opts ...func(*serverOptions)) *Server {
@@ -63,6 +66,7 @@ func NewServer(appName string, env string, version string, db *pgxpool.Pool, red
 		audit:      defaultAudit(options.audit),
 		files:      options.files,
 		orgs:       options.orgs,
+		apiKeys:    options.apiKeys,
 	}
 }
 
@@ -122,21 +126,36 @@ func WithOrgs(service *orgs.Service) func(*serverOptions) {
 	}
 }
 
+func WithAPIKeys(service *apikeys.Service) func(*serverOptions) {
+	return func(opts *serverOptions) {
+		opts.apiKeys = service
+	}
+}
+
 func (s *Server) Handler() http.Handler {
 	mux := http.NewServeMux()
 	mux.HandleFunc("GET /healthz", s.healthz)
 	mux.HandleFunc("GET /readyz", s.readyz)
 	mux.HandleFunc("GET /api/v1/meta", s.meta)
 	mux.HandleFunc("GET /api/v1/auth/me", s.requireAuth(s.authMe))
+	mux.HandleFunc("GET /api/v1/auth/tokens", s.requireAuth(s.authTokensList))
+	mux.HandleFunc("POST /api/v1/auth/tokens", s.requireAuth(s.authTokensCreate))
+	mux.HandleFunc("DELETE /api/v1/auth/tokens/{id}", s.requireAuth(s.authTokensRevoke))
+	mux.HandleFunc("GET /api/v1/auth/identities", s.requireAuth(s.authIdentitiesList))
+	mux.HandleFunc("POST /api/v1/auth/identities", s.requireAuth(s.authIdentitiesLink))
+	mux.HandleFunc("DELETE /api/v1/auth/identities/{id}", s.requireAuth(s.authIdentitiesUnlink))
 	mux.HandleFunc("GET /api/v1/orgs", s.requireAuth(s.orgsList))
 	mux.HandleFunc("POST /api/v1/orgs", s.requireAuth(s.orgsCreate))
 	mux.HandleFunc("GET /api/v1/org/members", s.requireOrgRole(orgRoleAdmin, s.orgMembersList))
-	mux.HandleFunc("POST /api/v1/org/invites", s.requireOrgRole(orgRoleAdmin, s.orgInvitesCreate))
+	mux.HandleFunc("POST /api/v1/org/invites", s.requireOrgRole(orgRoleAdmin, denyAPIKeys(s.orgInvitesCreate)))
 	mux.HandleFunc("POST /api/v1/org/invites/accept", s.requireAuth(s.orgInvitesAccept))
-	mux.HandleFunc("PATCH /api/v1/org/members/{userId}", s.requireOrgRole(orgRoleOwner, s.orgMembersUpdateRole))
-	mux.HandleFunc("DELETE /api/v1/org/members/{userId}", s.requireOrgRole(orgRoleOwner, s.orgMembersRemove))
-	mux.HandleFunc("POST /api/v1/billing/checkout-session", s.requireOrgRole(orgRoleAdmin, s.billingCheckoutSession))
-	mux.HandleFunc("POST /api/v1/billing/portal-session", s.requireOrgRole(orgRoleAdmin, s.billingPortalSession))
+	mux.HandleFunc("GET /api/v1/org/api-keys", s.requireOrgRole(orgRoleAdmin, denyAPIKeys(s.apiKeysList)))
+	mux.HandleFunc("POST /api/v1/org/api-keys", s.requireOrgRole(orgRoleAdmin, denyAPIKeys(s.apiKeysCreate)))
+	mux.HandleFunc("DELETE /api/v1/org/api-keys/{id}", s.requireOrgRole(orgRoleAdmin, denyAPIKeys(s.apiKeysRevoke)))
+	mux.HandleFunc("PATCH /api/v1/org/members/{userId}", s.requireOrgRole(orgRoleOwner, denyAPIKeys(s.orgMembersUpdateRole)))
+	mux.HandleFunc("DELETE /api/v1/org/members/{userId}", s.requireOrgRole(orgRoleOwner, denyAPIKeys(s.orgMembersRemove)))
+	mux.HandleFunc("POST /api/v1/billing/checkout-session", s.requireOrgRole(orgRoleAdmin, denyAPIKeys(s.billingCheckoutSession)))
+	mux.HandleFunc("POST /api/v1/billing/portal-session", s.requireOrgRole(orgRoleAdmin, denyAPIKeys(s.billingPortalSession)))
 	mux.HandleFunc("POST /api/v1/billing/webhook", s.billingWebhook)
 	mux.HandleFunc("GET /api/v1/audit/events", s.requireOrgRole(orgRoleAdmin, s.auditEvents))
 	mux.HandleFunc("POST /api/v1/files/upload-url", s.requireOrg(s.filesUploadURL))
@@ -202,23 +221,35 @@ func withCommonMiddleware(next http.Handler) http.Handler {
 type authContextKey string
 
 const (
-	authUserContextKey authContextKey = "auth_user"
-	authOrgContextKey  authContextKey = "auth_org"
+	authUserContextKey   authContextKey = "auth_user"
+	authOrgContextKey    authContextKey = "auth_org"
+	authAPIKeyContextKey authContextKey = "auth_api_key"
+	authPATContextKey    authContextKey = "auth_pat"
 )
 
 func (s *Server) requireAuth(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		token, err := auth.ExtractBearerToken(r)
+		if err == nil && s.apiKeys != nil && apikeys.IsToken(token) {
+			s.authenticateAPIKey(w, r, token, next)
+			return
+		}
+
 		if s.auth == nil {
 			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "auth_not_configured"})
 			return
 		}
 
-		token, err := auth.ExtractBearerToken(r)
 		if err != nil {
 			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "missing_or_invalid_token"})
 			return
 		}
 
+		if auth.IsPersonalAccessToken(token) {
+			s.authenticatePAT(w, r, token, next)
+			return
+		}
+
 		user, err := s.auth.Authenticate(r.Context(), token)
 		if err != nil {
 			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "authentication_failed"})
@@ -236,8 +267,62 @@ func (s *Server) requireAuth(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
+// authenticateAPIKey handles requests bearing an organization API key. The key
+// resolves straight to its organization, so requireOrg skips membership lookup.
+func (s *Server) authenticateAPIKey(w http.ResponseWriter, r *http.Request, token string, next http.HandlerFunc) {
+	principal, err := s.apiKeys.Authenticate(r.Context(), token)
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "authentication_failed"})
+		return
+	}
+
+	if !apikeys.AllowsMethod(principal.Key.Scopes, r.Method) {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "insufficient_scope"})
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), authAPIKeyContextKey, principal.Key)
+	ctx = context.WithValue(ctx, authOrgContextKey, auth.Organization{
+		ID:   principal.Organization.ID,
+		Name: principal.Organization.Name,
+		Slug: principal.Organization.Slug,
+		Kind: principal.Organization.Kind,
+		Role: apikeys.OrgRole(principal.Key.Scopes),
+	})
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// authenticatePAT handles requests bearing a personal access token. The token
+// acts as its user, so organization selection works as for a session.
+func (s *Server) authenticatePAT(w http.ResponseWriter, r *http.Request, token string, next http.HandlerFunc) {
+	user, pat, err := s.auth.AuthenticateToken(r.Context(), token)
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "authentication_failed"})
+		return
+	}
+
+	if !apikeys.AllowsMethod(pat.Scopes, r.Method) {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "insufficient_scope"})
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), authUserContextKey, user)
+	ctx = context.WithValue(ctx, authPATContextKey, pat)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
 func (s *Server) requireOrg(next http.HandlerFunc) http.HandlerFunc {
 	return s.requireAuth(func(w http.ResponseWriter, r *http.Request) {
+		if org := authOrgFromContext(r.Context()); org.ID != "" {
+			requestedOrgID := strings.TrimSpace(r.Header.Get("X-Organization-ID"))
+			if requestedOrgID != "" && requestedOrgID != org.ID {
+				writeJSON(w, http.StatusForbidden, map[string]string{"error": "organization_not_found"})
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
 		user := authUserFromContext(r.Context())
 		if user.ID == "" {
 			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "user_not_found"})
@@ -255,6 +340,10 @@ func (s *Server) requireOrg(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
+		if pat := patFromContext(r.Context()); pat.ID != "" {
+			org.Role = patOrgRole(org.Role, pat.Scopes)
+		}
+
 		ctx := context.WithValue(r.Context(), authOrgContextKey, org)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
@@ -263,6 +352,13 @@ func (s *Server) requireOrg(next http.HandlerFunc) http.HandlerFunc {
 func (s *Server) authMe(w http.ResponseWriter, r *http.Request) {
 	user := authUserFromContext(r.Context())
 	org := authOrgFromContext(r.Context())
+	if key := apiKeyFromContext(r.Context()); key.ID != "" {
+		writeJSON(w, http.StatusOK, map[string]any{
+			"apiKey":       key,
+			"organization": org,
+		})
+		return
+	}
 	if org.ID == "" {
 		resolved, err := s.auth.ResolveOrganization(r.Context(), user.ID, "")
 		if err == nil {
@@ -412,6 +508,43 @@ func authUserFromContext(ctx context.Context) auth.User {
 	return user
 }
 
+func apiKeyFromContext(ctx context.Context) apikeys.Key {
+	key, ok := ctx.Value(authAPIKeyContextKey).(apikeys.Key)
+	if !ok {
+		return apikeys.Key{}
+	}
+	return key
+}
+
+// actorDistinctID is the analytics distinct ID for the caller: the user, or
+// "api_key:<id>" for requests made with an organization API key.
+func actorDistinctID(ctx context.Context) string {
+	if user := authUserFromContext(ctx); user.ID != "" {
+		return user.ID
+	}
+	if key := apiKeyFromContext(ctx); key.ID != "" {
+		return "api_key:" + key.ID
+	}
+	return ""
+}
+
+// withActorData adds the API key ID to audit data for requests made with an
+// organization API key, which carry no user to attribute the event to.
+func withActorData(ctx context.Context, data map[string]any) map[string]any {
+	if key := apiKeyFromContext(ctx); key.ID != "" {
+		data["api_key_id"] = key.ID
+	}
+	return data
+}
+
+func patFromContext(ctx context.Context) auth.PersonalAccessToken {
+	pat, ok := ctx.Value(authPATContextKey).(auth.PersonalAccessToken)
+	if !ok {
+		return auth.PersonalAccessToken{}
+	}
+	return pat
+}
+
 func authOrgFromContext(ctx context.Context) auth.Organization {
 	org, ok := ctx.Value(authOrgContextKey).(auth.Organization)
 	if !ok {
@@ -502,14 +635,14 @@ func (s *Server) filesUploadURL(w http.ResponseWriter, r *http.Request) {
 
 	s.analytics.Track(r.Context(), analytics.Event{
 		Name:       "file_upload_url_created",
-		DistinctID: user.ID,
+		DistinctID: actorDistinctID(r.Context()),
 		Properties: map[string]any{"organization_id": org.ID},
 	})
 	_ = s.audit.Record(r.Context(), audit.Event{
 		OrganizationID: org.ID,
 		UserID:         user.ID,
 		Action:         "file_upload_url_created",
-		Data:           map[string]any{"filename": req.Filename},
+		Data:           withActorData(r.Context(), map[string]any{"filename": req.Filename}),
 	})
 
 	writeJSON(w, http.StatusOK, resp)
@@ -653,14 +786,14 @@ func (s *Server) filesComplete(w http.ResponseWriter, r *http.Request) {
 
 	s.analytics.Track(r.Context(), analytics.Event{
 		Name:       "file_uploaded",
-		DistinctID: user.ID,
+		DistinctID: actorDistinctID(r.Context()),
 		Properties: map[string]any{"organization_id": org.ID},
 	})
 	_ = s.audit.Record(r.Context(), audit.Event{
 		OrganizationID: org.ID,
 		UserID:         user.ID,
 		Action:         "file_uploaded",
-		Data:           map[string]any{"file_id": fileID, "size_bytes": req.SizeBytes},
+		Data:           withActorData(r.Context(), map[string]any{"file_id": fileID, "size_bytes": req.SizeBytes}),
 	})
 
 	writeJSON(w, http.StatusOK, map[string]string{"status": "uploaded"})
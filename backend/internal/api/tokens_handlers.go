@@ -0,0 +1,95 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"saas-core-template/backend/internal/apikeys"
+	"saas-core-template/backend/internal/auth"
+)
+
+func (s *Server) authTokensList(w http.ResponseWriter, r *http.Request) {
+	user := authUserFromContext(r.Context())
+	if user.ID == "" {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "user_not_found"})
+		return
+	}
+
+	tokens, err := s.auth.ListTokens(r.Context(), user.ID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed_to_list_tokens"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"tokens": tokens})
+}
+
+func (s *Server) authTokensCreate(w http.ResponseWriter, r *http.Request) {
+	// A PAT acts as its user, so one must not be able to issue further PATs
+	// that would outlive its own expiry or revocation.
+	user := authUserFromContext(r.Context())
+	if user.ID == "" || patFromContext(r.Context()).ID != "" {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "user_session_required"})
+		return
+	}
+
+	var req struct {
+		Name      string     `json:"name"`
+		Scopes    []string   `json:"scopes"`
+		ExpiresAt *time.Time `json:"expiresAt"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request_body"})
+		return
+	}
+
+	token, secret, err := s.auth.CreateToken(r.Context(), auth.CreateTokenInput{
+		UserID:    user.ID,
+		Name:      req.Name,
+		Scopes:    req.Scopes,
+		ExpiresAt: req.ExpiresAt,
+	})
+	if err != nil {
+		if errors.Is(err, apikeys.ErrInvalidScope) {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_scope"})
+			return
+		}
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "failed_to_create_token"})
+		return
+	}
+
+	// Shown once for the user to paste into their CLI; ListTokens only ever
+	// returns the display prefix.
+	writeJSON(w, http.StatusOK, map[string]any{
+		"token":  token,
+		"secret": secret,
+	})
+}
+
+func (s *Server) authTokensRevoke(w http.ResponseWriter, r *http.Request) {
+	user := authUserFromContext(r.Context())
+	if user.ID == "" {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "user_not_found"})
+		return
+	}
+
+	tokenID := strings.TrimSpace(r.PathValue("id"))
+	if tokenID == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "missing_token_id"})
+		return
+	}
+
+	if err := s.auth.RevokeToken(r.Context(), user.ID, tokenID); err != nil {
+		if errors.Is(err, auth.ErrTokenNotFound) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "token_not_found"})
+			return
+		}
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed_to_revoke_token"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"status": "revoked"})
+}
@@ -0,0 +1,260 @@
+package apikeys
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"saas-core-template/backend/internal/audit"
+	"saas-core-template/backend/internal/db"
+)
+
+const (
+	// TokenPrefix marks organization API keys so they can be told apart from
+	// provider session tokens without a lookup.
+	TokenPrefix = "sk_"
+
+	secretBytes   = 32
+	displayLength = len(TokenPrefix) + 8
+
+	// lastUsedResolution limits last_used_at writes to one per key per minute.
+	lastUsedResolution = time.Minute
+)
+
+var (
+	ErrNotFound     = errors.New("api key not found")
+	ErrInvalidKey   = errors.New("invalid api key")
+	ErrInvalidScope = errors.New("invalid api key scope")
+)
+
+type Service struct {
+	db    *pgxpool.Pool
+	audit audit.Recorder
+}
+
+type Key struct {
+	ID              string     `json:"id"`
+	OrganizationID  string     `json:"organizationId"`
+	Name            string     `json:"name"`
+	Prefix          string     `json:"prefix"`
+	Scopes          []string   `json:"scopes"`
+	CreatedByUserID string     `json:"createdByUserId,omitempty"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	LastUsedAt      *time.Time `json:"lastUsedAt,omitempty"`
+	ExpiresAt       *time.Time `json:"expiresAt,omitempty"`
+	RevokedAt       *time.Time `json:"revokedAt,omitempty"`
+}
+
+type Organization struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+	Kind string `json:"kind"`
+}
+
+// Principal is what an authenticated API key resolves to.
+type Principal struct {
+	Key          Key
+	Organization Organization
+}
+
+type CreateInput struct {
+	OrganizationID  string
+	CreatedByUserID string
+	Name            string
+	Scopes          []string
+	ExpiresAt       *time.Time
+}
+
+func NewService(db *pgxpool.Pool, opts ...func(*Service)) *Service {
+	s := &Service{db: db, audit: audit.NewNoop()}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(s)
+		}
+	}
+	return s
+}
+
+func WithAudit(recorder audit.Recorder) func(*Service) {
+	return func(s *Service) {
+		if recorder != nil {
+			s.audit = recorder
+		}
+	}
+}
+
+// IsToken reports whether token looks like an API key.
+func IsToken(token string) bool {
+	return strings.HasPrefix(token, TokenPrefix)
+}
+
+// Create stores a new key and returns it with its secret. The secret is only
+// available here; afterwards just its hash and display prefix are kept.
+func (s *Service) Create(ctx context.Context, input CreateInput) (Key, string, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return Key{}, "", fmt.Errorf("missing name")
+	}
+
+	scopes, err := ParseScopes(input.Scopes)
+	if err != nil {
+		return Key{}, "", err
+	}
+
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(time.Now()) {
+		return Key{}, "", fmt.Errorf("expiry must be in the future")
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		return Key{}, "", fmt.Errorf("generate api key: %w", err)
+	}
+
+	row := s.db.QueryRow(ctx, `
+		INSERT INTO api_keys (organization_id, name, prefix, secret_hash, scopes, created_by_user_id, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6::uuid, $7)
+		RETURNING `+keyColumns,
+		input.OrganizationID, name, secret[:displayLength], db.HashSecret(secret), scopes, db.EmptyToNil(input.CreatedByUserID), input.ExpiresAt,
+	)
+	key, err := scanKey(row)
+	if err != nil {
+		return Key{}, "", fmt.Errorf("insert api key: %w", err)
+	}
+
+	_ = s.audit.Record(ctx, audit.Event{
+		OrganizationID: key.OrganizationID,
+		UserID:         input.CreatedByUserID,
+		Action:         "api_key_created",
+		Data:           map[string]any{"api_key_id": key.ID, "name": key.Name, "prefix": key.Prefix, "scopes": key.Scopes},
+	})
+
+	return key, secret, nil
+}
+
+func (s *Service) List(ctx context.Context, organizationID string) ([]Key, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT `+keyColumns+`
+		FROM api_keys
+		WHERE organization_id = $1
+		ORDER BY created_at DESC
+	`, organizationID)
+	if err != nil {
+		return nil, fmt.Errorf("list api keys: %w", err)
+	}
+	defer rows.Close()
+
+	out := []Key{}
+	for rows.Next() {
+		key, err := scanKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan api key: %w", err)
+		}
+		out = append(out, key)
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("list api keys rows: %w", rows.Err())
+	}
+	return out, nil
+}
+
+// Revoke disables a key immediately. Revoking an already revoked key is a no-op.
+func (s *Service) Revoke(ctx context.Context, organizationID string, keyID string, revokedByUserID string) error {
+	keyID, ok := db.ParseUUID(keyID)
+	if !ok {
+		return ErrNotFound
+	}
+
+	var alreadyRevoked bool
+	err := s.db.QueryRow(ctx, `
+		WITH target AS (
+			SELECT id, revoked_at IS NOT NULL AS already_revoked
+			FROM api_keys
+			WHERE organization_id = $1 AND id = $2::uuid
+		)
+		UPDATE api_keys k
+		SET revoked_at = COALESCE(k.revoked_at, now())
+		FROM target
+		WHERE k.id = target.id
+		RETURNING target.already_revoked
+	`, organizationID, keyID).Scan(&alreadyRevoked)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("revoke api key: %w", err)
+	}
+
+	if !alreadyRevoked {
+		_ = s.audit.Record(ctx, audit.Event{
+			OrganizationID: organizationID,
+			UserID:         revokedByUserID,
+			Action:         "api_key_revoked",
+			Data:           map[string]any{"api_key_id": keyID},
+		})
+	}
+	return nil
+}
+
+// Authenticate resolves a bearer token to its key and owning organization.
+// Unknown, revoked and expired keys all return ErrInvalidKey.
+func (s *Service) Authenticate(ctx context.Context, token string) (Principal, error) {
+	if !IsToken(token) {
+		return Principal{}, ErrInvalidKey
+	}
+
+	var principal Principal
+	row := s.db.QueryRow(ctx, `
+		SELECT `+qualifiedKeyColumns+`, o.name, o.slug, o.kind
+		FROM api_keys k
+		INNER JOIN organizations o ON o.id = k.organization_id
+		WHERE k.secret_hash = $1
+		  AND k.revoked_at IS NULL
+		  AND (k.expires_at IS NULL OR k.expires_at > now())
+	`, db.HashSecret(token))
+	key, err := scanKey(row, &principal.Organization.Name, &principal.Organization.Slug, &principal.Organization.Kind)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Principal{}, ErrInvalidKey
+		}
+		return Principal{}, fmt.Errorf("load api key: %w", err)
+	}
+	principal.Key = key
+	principal.Organization.ID = key.OrganizationID
+
+	// Best effort: a failed timestamp update must not fail the request.
+	_, _ = s.db.Exec(ctx, `
+		UPDATE api_keys
+		SET last_used_at = now()
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < now() - ($2::int * interval '1 second'))
+	`, key.ID, int(lastUsedResolution.Seconds()))
+
+	return principal, nil
+}
+
+const keyColumns = `id::text, organization_id::text, name, prefix, scopes, COALESCE(created_by_user_id::text, ''), created_at, last_used_at, expires_at, revoked_at`
+
+const qualifiedKeyColumns = `k.id::text, k.organization_id::text, k.name, k.prefix, k.scopes, COALESCE(k.created_by_user_id::text, ''), k.created_at, k.last_used_at, k.expires_at, k.revoked_at`
+
+func scanKey(row pgx.Row, extra ...any) (Key, error) {
+	var key Key
+	dest := []any{&key.ID, &key.OrganizationID, &key.Name, &key.Prefix, &key.Scopes, &key.CreatedByUserID, &key.CreatedAt, &key.LastUsedAt, &key.ExpiresAt, &key.RevokedAt}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return Key{}, err
+	}
+	return key, nil
+}
+
+func newSecret() (string, error) {
+	buf := make([]byte, secretBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return TokenPrefix + hex.EncodeToString(buf), nil
+}
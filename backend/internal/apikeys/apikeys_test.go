@@ -0,0 +1,69 @@
+package apikeys
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"saas-core-template/backend/internal/db"
+)
+
+func TestParseScopes(t *testing.T) {
+	got, err := ParseScopes([]string{" Write ", "read", "write"})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if strings.Join(got, ",") != "write,read" {
+		t.Fatalf("expected write,read, got %v", got)
+	}
+
+	if got, _ := ParseScopes(nil); len(got) != 1 || got[0] != "read" {
+		t.Fatalf("expected default read scope, got %v", got)
+	}
+
+	if _, err := ParseScopes([]string{"root"}); !errors.Is(err, ErrInvalidScope) {
+		t.Fatalf("expected ErrInvalidScope, got %v", err)
+	}
+}
+
+func TestScopeChecks(t *testing.T) {
+	cases := []struct {
+		name   string
+		scopes []string
+		method string
+		want   bool
+	}{
+		{"read allows GET", []string{"read"}, http.MethodGet, true},
+		{"read denies POST", []string{"read"}, http.MethodPost, false},
+		{"write allows DELETE", []string{"write"}, http.MethodDelete, true},
+		{"write implies read", []string{"write"}, http.MethodGet, true},
+		{"admin implies write", []string{"admin"}, http.MethodPatch, true},
+		{"unknown scope grants nothing", []string{"bogus"}, http.MethodGet, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := AllowsMethod(tc.scopes, tc.method); got != tc.want {
+				t.Fatalf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+
+	if OrgRole([]string{"read", "write"}) != "member" || OrgRole([]string{"admin"}) != "admin" {
+		t.Fatalf("unexpected org role mapping")
+	}
+}
+
+func TestNewSecret(t *testing.T) {
+	a, err := newSecret()
+	if err != nil {
+		t.Fatalf("new secret: %v", err)
+	}
+	b, _ := newSecret()
+	if !IsToken(a) || len(a) != len(TokenPrefix)+2*secretBytes || a == b {
+		t.Fatalf("unexpected secrets %q %q", a, b)
+	}
+	if db.HashSecret(a) == a || len(db.HashSecret(a)) != 64 {
+		t.Fatalf("unexpected hash %q", db.HashSecret(a))
+	}
+}
@@ -0,0 +1,86 @@
+package apikeys
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type Scope string
+
+// Scopes are cumulative: write includes read, admin includes write.
+const (
+	ScopeRead  Scope = "read"
+	ScopeWrite Scope = "write"
+	ScopeAdmin Scope = "admin"
+)
+
+func (s Scope) rank() (int, bool) {
+	switch s {
+	case ScopeRead:
+		return 1, true
+	case ScopeWrite:
+		return 2, true
+	case ScopeAdmin:
+		return 3, true
+	default:
+		return 0, false
+	}
+}
+
+// ParseScopes normalizes and validates requested scopes, defaulting to read.
+func ParseScopes(values []string) ([]string, error) {
+	seen := map[string]bool{}
+	var out []string
+	for _, value := range values {
+		scope := strings.ToLower(strings.TrimSpace(value))
+		if scope == "" || seen[scope] {
+			continue
+		}
+		if _, ok := Scope(scope).rank(); !ok {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidScope, value)
+		}
+		seen[scope] = true
+		out = append(out, scope)
+	}
+	if len(out) == 0 {
+		out = []string{string(ScopeRead)}
+	}
+	return out, nil
+}
+
+func highest(scopes []string) int {
+	best := 0
+	for _, scope := range scopes {
+		if rank, ok := Scope(scope).rank(); ok && rank > best {
+			best = rank
+		}
+	}
+	return best
+}
+
+// Has reports whether scopes grant required, directly or through a broader scope.
+func Has(scopes []string, required Scope) bool {
+	rank, ok := required.rank()
+	return ok && highest(scopes) >= rank
+}
+
+// AllowsMethod reports whether scopes permit an HTTP method: safe methods need
+// read, everything else needs write.
+func AllowsMethod(scopes []string, method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return Has(scopes, ScopeRead)
+	default:
+		return Has(scopes, ScopeWrite)
+	}
+}
+
+// OrgRole is the organization role a key acts with for role-gated routes:
+// admin keys act as admins, all others as members.
+func OrgRole(scopes []string) string {
+	if Has(scopes, ScopeAdmin) {
+		return "admin"
+	}
+	return "member"
+}
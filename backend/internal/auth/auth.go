@@ -8,8 +8,11 @@ import (
 	"strings"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"saas-core-template/backend/internal/audit"
+	"saas-core-template/backend/internal/db"
+	"saas-core-template/backend/internal/email"
 	"saas-core-template/backend/internal/jobs"
 )
 
@@ -24,6 +27,8 @@ type VerifiedPrincipal struct {
 	ProviderUserID string
 	PrimaryEmail   string
 	EmailVerified  bool
+	// ExpiresAt is when the verified token stops being valid; zero if unknown.
+	ExpiresAt time.Time
 }
 
 type Provider interface {
@@ -33,8 +38,11 @@ type Provider interface {
 type Service struct {
 	provider Provider
 	db       *pgxpool.Pool
-	jobs     jobs.Enqueuer
+	jobs     jobs.TxEnqueuer
 	audit    audit.Recorder
+
+	sessions   SessionCache
+	sessionTTL time.Duration
 }
 
 type User struct {
@@ -67,7 +75,7 @@ func NewService(provider Provider, db *pgxpool.Pool, opts ...func(*Service)) *Se
 	return svc
 }
 
-func WithJobs(enqueuer jobs.Enqueuer) func(*Service) {
+func WithJobs(enqueuer jobs.TxEnqueuer) func(*Service) {
 	return func(s *Service) {
 		s.jobs = enqueuer
 	}
@@ -95,7 +103,18 @@ func ExtractBearerToken(r *http.Request) (string, error) {
 	return strings.TrimSpace(parts[1]), nil
 }
 
+// Authenticate resolves a provider session token to its user. Personal access
+// tokens are rejected: they carry scopes the caller must enforce, so they go
+// through AuthenticateToken instead.
 func (s *Service) Authenticate(ctx context.Context, token string) (User, error) {
+	if IsPersonalAccessToken(token) {
+		return User{}, ErrUnauthorized
+	}
+
+	if user, ok := s.cachedUser(ctx, token); ok {
+		return user, nil
+	}
+
 	principal, err := s.provider.VerifyToken(ctx, token)
 	if err != nil {
 		return User{}, fmt.Errorf("verify token: %w", err)
@@ -121,55 +140,81 @@ func (s *Service) Authenticate(ctx context.Context, token string) (User, error)
 			Action: "user_created",
 			Data:   map[string]any{"primary_email": user.PrimaryEmail, "provider": principal.Provider},
 		})
-
-		if s.jobs != nil && strings.TrimSpace(user.PrimaryEmail) != "" {
-			_, _ = s.jobs.Enqueue(ctx, "send_email", map[string]any{
-				"kind":    "welcome",
-				"to":      user.PrimaryEmail,
-				"subject": "Welcome",
-				"text":    "Welcome to the app. You're set up and ready to go.",
-			}, time.Now().UTC())
-		}
 	}
 
+	s.cacheUser(ctx, token, principal, user)
+
 	return user, nil
 }
 
+// ensureUserIdentity maps principal to an internal user. A new identity links
+// to the user owning the same verified email, or else creates a new user. For
+// known identities it only writes when the provider's email
+// claims differ from what is stored, so steady-state requests are read-only.
+// Emails compare case-insensitively, and only the user's oldest identity (the
+// one that created it) may change users.primary_email once it is set, so linked
+// providers reporting different addresses do not overwrite each other.
 func (s *Service) ensureUserIdentity(ctx context.Context, principal VerifiedPrincipal) (string, bool, error) {
+	var (
+		userID        string
+		providerEmail string
+		emailVerified bool
+		primaryEmail  string
+		ownsEmail     bool
+	)
+	err := s.db.QueryRow(ctx, `
+		SELECT ai.user_id::text, COALESCE(ai.provider_email, ''), ai.email_verified_at IS NOT NULL, COALESCE(u.primary_email, ''),
+		       ai.id = (
+		           SELECT first.id
+		           FROM auth_identities first
+		           WHERE first.user_id = ai.user_id
+		           ORDER BY first.created_at ASC, first.id ASC
+		           LIMIT 1
+		       )
+		FROM auth_identities ai
+		INNER JOIN users u ON u.id = ai.user_id
+		WHERE ai.provider = $1 AND ai.provider_user_id = $2
+	`, principal.Provider, principal.ProviderUserID).Scan(&userID, &providerEmail, &emailVerified, &primaryEmail, &ownsEmail)
+	if err == nil {
+		email := strings.TrimSpace(principal.PrimaryEmail)
+		identityChanged := (email != "" && !strings.EqualFold(email, providerEmail)) || (principal.EmailVerified && !emailVerified)
+		userChanged := email != "" && !strings.EqualFold(email, primaryEmail) && (ownsEmail || primaryEmail == "")
+		if !identityChanged && !userChanged {
+			return userID, false, nil
+		}
+
+		if err := s.syncIdentity(ctx, principal, userID, identityChanged, userChanged); err != nil {
+			return "", false, err
+		}
+		return userID, false, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return "", false, fmt.Errorf("load identity: %w", err)
+	}
+
 	tx, err := s.db.Begin(ctx)
 	if err != nil {
 		return "", false, fmt.Errorf("begin tx: %w", err)
 	}
 	defer tx.Rollback(ctx)
 
-	var userID string
-	err = tx.QueryRow(ctx, `
-		SELECT user_id::text
-		FROM auth_identities
-		WHERE provider = $1 AND provider_user_id = $2
-	`, principal.Provider, principal.ProviderUserID).Scan(&userID)
-	if err == nil {
-		if _, err := tx.Exec(ctx, `
-			UPDATE auth_identities
-			SET provider_email = COALESCE($1, provider_email),
-			    email_verified_at = CASE WHEN $2 THEN now() ELSE email_verified_at END,
-			    updated_at = now()
-			WHERE provider = $3 AND provider_user_id = $4
-			`, emptyToNil(principal.PrimaryEmail), principal.EmailVerified, principal.Provider, principal.ProviderUserID); err != nil {
-			return "", false, fmt.Errorf("update identity: %w", err)
+	// A verified email already verified on another provider's identity links to
+	// that user, so moving between providers keeps one internal user.
+	if email := strings.TrimSpace(principal.PrimaryEmail); principal.EmailVerified && email != "" && linksByVerifiedEmail(principal.Provider) {
+		existingUserID, found, err := userForVerifiedEmail(ctx, tx, principal.Provider, email)
+		if err != nil {
+			return "", false, err
 		}
-
-		if principal.PrimaryEmail != "" {
-			if _, err := tx.Exec(ctx, `UPDATE users SET primary_email = $1, updated_at = now() WHERE id = $2`, principal.PrimaryEmail, userID); err != nil {
-				return "", false, fmt.Errorf("update user email: %w", err)
+		if found {
+			if _, err := upsertIdentity(ctx, tx, existingUserID, principal); err != nil {
+				return "", false, err
 			}
+			if err := tx.Commit(ctx); err != nil {
+				return "", false, fmt.Errorf("commit linked identity: %w", err)
+			}
+			s.recordIdentityLinked(ctx, existingUserID, principal, "verified_email")
+			return existingUserID, false, nil
 		}
-
-		if err := tx.Commit(ctx); err != nil {
-			return "", false, fmt.Errorf("commit existing identity: %w", err)
-		}
-
-		return userID, false, nil
 	}
 
 	// Create new user and identity mapping when no existing identity is found.
@@ -177,17 +222,29 @@ func (s *Service) ensureUserIdentity(ctx context.Context, principal VerifiedPrin
 		INSERT INTO users (primary_email)
 		VALUES ($1)
 		RETURNING id::text
-	`, emptyToNil(principal.PrimaryEmail)).Scan(&userID); err != nil {
+	`, db.EmptyToNil(principal.PrimaryEmail)).Scan(&userID); err != nil {
 		return "", false, fmt.Errorf("insert user: %w", err)
 	}
 
 	if _, err := tx.Exec(ctx, `
 		INSERT INTO auth_identities (user_id, provider, provider_user_id, provider_email, email_verified_at)
 		VALUES ($1, $2, $3, $4, CASE WHEN $5 THEN now() ELSE NULL END)
-	`, userID, principal.Provider, principal.ProviderUserID, emptyToNil(principal.PrimaryEmail), principal.EmailVerified); err != nil {
+	`, userID, principal.Provider, principal.ProviderUserID, db.EmptyToNil(principal.PrimaryEmail), principal.EmailVerified); err != nil {
 		return "", false, fmt.Errorf("insert identity: %w", err)
 	}
 
+	// Enqueue the welcome email with the new user so it is sent exactly when the user exists.
+	if s.jobs != nil && strings.TrimSpace(principal.PrimaryEmail) != "" {
+		if _, err := s.jobs.EnqueueTx(ctx, tx, email.SendJobType, email.SendJob{
+			Kind:    "welcome",
+			To:      strings.TrimSpace(principal.PrimaryEmail),
+			Subject: "Welcome",
+			Text:    "Welcome to the app. You're set up and ready to go.",
+		}, time.Now().UTC(), jobs.WithUniqueKey("welcome_email:"+userID, jobs.OnConflictSkip)); err != nil {
+			return "", false, fmt.Errorf("enqueue welcome email: %w", err)
+		}
+	}
+
 	if err := tx.Commit(ctx); err != nil {
 		return "", false, fmt.Errorf("commit new identity: %w", err)
 	}
@@ -195,6 +252,45 @@ func (s *Service) ensureUserIdentity(ctx context.Context, principal VerifiedPrin
 	return userID, true, nil
 }
 
+func (s *Service) syncIdentity(ctx context.Context, principal VerifiedPrincipal, userID string, identityChanged bool, userChanged bool) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if identityChanged {
+		// A new email is only verified if the provider says so; the old
+		// verification must not carry over to it.
+		if _, err := tx.Exec(ctx, `
+			UPDATE auth_identities
+			SET provider_email = COALESCE($1, provider_email),
+			    email_verified_at = CASE
+			        WHEN $1::text IS NOT NULL AND lower($1::text) IS DISTINCT FROM lower(provider_email) THEN
+			            CASE WHEN $2 THEN now() ELSE NULL END
+			        WHEN $2 THEN COALESCE(email_verified_at, now())
+			        ELSE email_verified_at
+			    END,
+			    updated_at = now()
+			WHERE provider = $3 AND provider_user_id = $4
+		`, db.EmptyToNil(strings.TrimSpace(principal.PrimaryEmail)), principal.EmailVerified, principal.Provider, principal.ProviderUserID); err != nil {
+			return fmt.Errorf("update identity: %w", err)
+		}
+	}
+
+	if userChanged {
+		if _, err := tx.Exec(ctx, `UPDATE users SET primary_email = $1, updated_at = now() WHERE id = $2`, strings.TrimSpace(principal.PrimaryEmail), userID); err != nil {
+			return fmt.Errorf("update user email: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit existing identity: %w", err)
+	}
+
+	return nil
+}
+
 func (s *Service) ResolveOrganization(ctx context.Context, userID string, requestedOrgID string) (Organization, error) {
 	query := `
 		SELECT o.id::text, o.name, o.slug, o.kind, om.role
@@ -219,14 +315,6 @@ func (s *Service) ResolveOrganization(ctx context.Context, userID string, reques
 	return org, nil
 }
 
-func emptyToNil(value string) any {
-	if strings.TrimSpace(value) == "" {
-		return nil
-	}
-
-	return strings.TrimSpace(value)
-}
-
 func (s *Service) ensureDefaultOrganizationForUser(ctx context.Context, user User) error {
 	var membershipCount int
 	if err := s.db.QueryRow(ctx, `
@@ -1,96 +1,156 @@
 package auth
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 )
 
 const (
 	defaultClerkAPIBaseURL = "https://api.clerk.com"
 	authProviderClerk      = "clerk"
+
+	// Clerk session tokens live for 60 seconds, so keep the leeway small.
+	clerkClockSkew = 5 * time.Second
+
+	clerkEmailCacheTTL  = 10 * time.Minute
+	clerkEmailCacheSize = 10000
 )
 
+// ClerkProvider verifies Clerk session JWTs locally against the instance's
+// JWKS. The primary email comes from the token when the session token is
+// customized to include it; otherwise it is fetched from the users endpoint
+// and cached per user.
 type ClerkProvider struct {
-	secretKey string
-	apiBase   string
-	client    *http.Client
+	secretKey         string
+	apiBase           string
+	client            *http.Client
+	keys              *jwksCache
+	want              claimExpectations
+	authorizedParties []string
+	emails            *emailCache
+	now               func() time.Time
 }
 
-func NewClerkProvider(secretKey string, apiBase string) *ClerkProvider {
+func NewClerkProvider(secretKey string, apiBase string, opts ...func(*ClerkProvider)) *ClerkProvider {
 	base := strings.TrimSpace(apiBase)
 	if base == "" {
 		base = defaultClerkAPIBaseURL
 	}
 
-	return &ClerkProvider{
+	p := &ClerkProvider{
 		secretKey: strings.TrimSpace(secretKey),
 		apiBase:   strings.TrimRight(base, "/"),
 		client: &http.Client{
 			Timeout: 10 * time.Second,
 		},
+		want:   claimExpectations{ClockSkew: clerkClockSkew},
+		emails: newEmailCache(clerkEmailCacheTTL, clerkEmailCacheSize),
+		now:    time.Now,
+	}
+
+	for _, opt := range opts {
+		if opt != nil {
+			opt(p)
+		}
+	}
+
+	p.keys = newJWKSCache(p.apiBase+"/v1/jwks", p.client)
+	p.keys.header = http.Header{"Authorization": {"Bearer " + p.secretKey}}
+
+	return p
+}
+
+// WithClerkIssuer requires the token's iss claim to equal issuer (the
+// instance's Frontend API URL).
+func WithClerkIssuer(issuer string) func(*ClerkProvider) {
+	return func(p *ClerkProvider) {
+		p.want.Issuer = strings.TrimSpace(issuer)
+	}
+}
+
+// WithAuthorizedParties rejects tokens whose azp claim is not one of origins,
+// which stops tokens minted for another site on the same instance from being
+// replayed here.
+func WithAuthorizedParties(origins ...string) func(*ClerkProvider) {
+	return func(p *ClerkProvider) {
+		for _, origin := range origins {
+			if trimmed := strings.TrimRight(strings.TrimSpace(origin), "/"); trimmed != "" {
+				p.authorizedParties = append(p.authorizedParties, trimmed)
+			}
+		}
 	}
 }
 
+type clerkClaims struct {
+	jwtClaims
+	AuthorizedParty string   `json:"azp"`
+	Email           string   `json:"email"`
+	EmailVerified   flexBool `json:"email_verified"`
+}
+
 func (p *ClerkProvider) VerifyToken(ctx context.Context, token string) (VerifiedPrincipal, error) {
-	userID, err := p.verifySessionToken(ctx, token)
-	if err != nil {
+	var claims clerkClaims
+	if err := verifyJWT(ctx, p.keys, token, p.want, p.now(), &claims); err != nil {
 		return VerifiedPrincipal{}, err
 	}
-
-	email, verified, err := p.fetchPrimaryEmail(ctx, userID)
-	if err != nil {
+	if err := p.checkAuthorizedParty(claims.AuthorizedParty); err != nil {
 		return VerifiedPrincipal{}, err
 	}
 
-	return VerifiedPrincipal{
+	principal := VerifiedPrincipal{
 		Provider:       authProviderClerk,
-		ProviderUserID: userID,
-		PrimaryEmail:   email,
-		EmailVerified:  verified,
-	}, nil
-}
+		ProviderUserID: claims.Subject,
+		ExpiresAt:      claims.ExpiresAt.Time,
+	}
 
-func (p *ClerkProvider) verifySessionToken(ctx context.Context, token string) (string, error) {
-	payload := map[string]string{"token": token}
-	body, _ := json.Marshal(payload)
+	// Without an email_verified claim the address is not treated as verified;
+	// customize the session token with both claims.
+	if email := strings.TrimSpace(claims.Email); email != "" {
+		principal.PrimaryEmail = email
+		principal.EmailVerified = bool(claims.EmailVerified)
+		return principal, nil
+	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.apiBase+"/v1/sessions/verify", bytes.NewReader(body))
+	email, verified, err := p.primaryEmail(ctx, claims.Subject)
 	if err != nil {
-		return "", fmt.Errorf("build clerk verify request: %w", err)
+		return VerifiedPrincipal{}, err
 	}
+	principal.PrimaryEmail = email
+	principal.EmailVerified = verified
 
-	req.Header.Set("Authorization", "Bearer "+p.secretKey)
-	req.Header.Set("Content-Type", "application/json")
+	return principal, nil
+}
 
-	resp, err := p.client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("call clerk verify session: %w", err)
+func (p *ClerkProvider) checkAuthorizedParty(azp string) error {
+	if len(p.authorizedParties) == 0 || azp == "" {
+		return nil
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		responseBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
-		return "", fmt.Errorf("clerk verify session status %d: %s", resp.StatusCode, string(responseBody))
+	for _, origin := range p.authorizedParties {
+		if azp == origin {
+			return nil
+		}
 	}
+	return fmt.Errorf("%w: unexpected azp %q", ErrInvalidToken, azp)
+}
 
-	var parsed struct {
-		UserID string `json:"user_id"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
-		return "", fmt.Errorf("decode clerk verify session: %w", err)
+func (p *ClerkProvider) primaryEmail(ctx context.Context, userID string) (string, bool, error) {
+	if entry, ok := p.emails.get(userID, p.now()); ok {
+		return entry.email, entry.verified, nil
 	}
 
-	if strings.TrimSpace(parsed.UserID) == "" {
-		return "", fmt.Errorf("clerk verify session missing user_id")
+	email, verified, err := p.fetchPrimaryEmail(ctx, userID)
+	if err != nil {
+		return "", false, err
 	}
+	p.emails.set(userID, emailCacheEntry{email: email, verified: verified}, p.now())
 
-	return parsed.UserID, nil
+	return email, verified, nil
 }
 
 func (p *ClerkProvider) fetchPrimaryEmail(ctx context.Context, userID string) (string, bool, error) {
@@ -140,3 +200,46 @@ func (p *ClerkProvider) fetchPrimaryEmail(ctx context.Context, userID string) (s
 
 	return "", false, nil
 }
+
+type emailCacheEntry struct {
+	email    string
+	verified bool
+	expires  time.Time
+}
+
+// emailCache remembers primary emails looked up from the provider. It is
+// bounded by dropping everything once full, which is cheap and good enough for
+// a cache whose entries expire within minutes anyway.
+type emailCache struct {
+	ttl     time.Duration
+	maxSize int
+
+	mu      sync.Mutex
+	entries map[string]emailCacheEntry
+}
+
+func newEmailCache(ttl time.Duration, maxSize int) *emailCache {
+	return &emailCache{ttl: ttl, maxSize: maxSize, entries: map[string]emailCacheEntry{}}
+}
+
+func (c *emailCache) get(userID string, now time.Time) (emailCacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[userID]
+	if !ok || !now.Before(entry.expires) {
+		return emailCacheEntry{}, false
+	}
+	return entry, true
+}
+
+func (c *emailCache) set(userID string, entry emailCacheEntry, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries) >= c.maxSize {
+		c.entries = map[string]emailCacheEntry{}
+	}
+	entry.expires = now.Add(c.ttl)
+	c.entries[userID] = entry
+}
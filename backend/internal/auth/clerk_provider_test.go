@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClerkProviderVerifyToken(t *testing.T) {
+	key := newRSAKey(t, "ins_1")
+	var userCalls atomic.Int32
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/jwks", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer sk_test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{key.jwk()}})
+	})
+	mux.HandleFunc("/v1/users/user_2", func(w http.ResponseWriter, r *http.Request) {
+		userCalls.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"primary_email_address_id": "idn_1",
+			"email_addresses": []map[string]any{
+				{"id": "idn_1", "email_address": "b@example.com", "verification": map[string]string{"status": "verified"}},
+			},
+		})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	provider := NewClerkProvider("sk_test", server.URL, WithAuthorizedParties("https://app.example.com/"))
+	now := time.Now()
+	claims := func(overrides map[string]any) map[string]any {
+		c := map[string]any{
+			"sub": "user_2", "azp": "https://app.example.com", "sid": "sess_1",
+			"iat": now.Unix(), "exp": now.Add(time.Minute).Unix(),
+		}
+		for k, v := range overrides {
+			c[k] = v
+		}
+		return c
+	}
+
+	t.Run("uses email from claims without calling clerk", func(t *testing.T) {
+		principal, err := provider.VerifyToken(context.Background(), key.sign(t, claims(map[string]any{"email": "c@example.com", "email_verified": true})))
+		if err != nil {
+			t.Fatalf("verify: %v", err)
+		}
+		if principal.PrimaryEmail != "c@example.com" || !principal.EmailVerified || principal.Provider != "clerk" {
+			t.Fatalf("unexpected principal %+v", principal)
+		}
+		if userCalls.Load() != 0 {
+			t.Fatalf("expected no users endpoint calls")
+		}
+	})
+
+	t.Run("falls back to users endpoint once", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			principal, err := provider.VerifyToken(context.Background(), key.sign(t, claims(nil)))
+			if err != nil {
+				t.Fatalf("verify: %v", err)
+			}
+			if principal.PrimaryEmail != "b@example.com" || !principal.EmailVerified {
+				t.Fatalf("unexpected principal %+v", principal)
+			}
+		}
+		if got := userCalls.Load(); got != 1 {
+			t.Fatalf("expected 1 users endpoint call, got %d", got)
+		}
+	})
+
+	t.Run("rejects foreign azp", func(t *testing.T) {
+		_, err := provider.VerifyToken(context.Background(), key.sign(t, claims(map[string]any{"azp": "https://evil.example"})))
+		if !errors.Is(err, ErrInvalidToken) {
+			t.Fatalf("expected ErrInvalidToken, got %v", err)
+		}
+	})
+
+	t.Run("rejects expired", func(t *testing.T) {
+		_, err := provider.VerifyToken(context.Background(), key.sign(t, claims(map[string]any{"exp": now.Add(-time.Minute).Unix()})))
+		if !errors.Is(err, ErrTokenExpired) {
+			t.Fatalf("expected ErrTokenExpired, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	authProviderDev = "dev"
+
+	// DevPlainTokenPrefix marks unsigned "dev:<email>" tokens, accepted only
+	// when APP_ENV=development and DevConfig.AllowPlainTokens is set.
+	DevPlainTokenPrefix = "dev:"
+
+	devClockSkew = 5 * time.Second
+)
+
+type DevConfig struct {
+	// Env is APP_ENV as explicitly set; pass "" when it was defaulted. The
+	// provider refuses to start unless it is "development" or "test".
+	Env string
+	// Secret signs HS256 dev tokens. Empty disables signed tokens.
+	Secret string
+	// AllowPlainTokens accepts unsigned "dev:<email>" tokens. It is refused
+	// unless Env is "development".
+	AllowPlainTokens bool
+}
+
+// DevProvider authenticates local and test traffic without an external
+// identity provider. It must never be enabled in production.
+type DevProvider struct {
+	secret     []byte
+	allowPlain bool
+	now        func() time.Time
+}
+
+func NewDevProvider(cfg DevConfig) (*DevProvider, error) {
+	env := strings.ToLower(strings.TrimSpace(cfg.Env))
+	if env == "" {
+		return nil, fmt.Errorf("dev auth provider requires APP_ENV to be set explicitly")
+	}
+	if env != "development" && env != "test" {
+		return nil, fmt.Errorf("dev auth provider is not allowed in %q environment", cfg.Env)
+	}
+	if cfg.AllowPlainTokens && env != "development" {
+		return nil, fmt.Errorf("plain dev tokens are only allowed in development")
+	}
+
+	p := &DevProvider{
+		secret:     []byte(strings.TrimSpace(cfg.Secret)),
+		allowPlain: cfg.AllowPlainTokens,
+		now:        time.Now,
+	}
+	if len(p.secret) == 0 && !p.allowPlain {
+		return nil, fmt.Errorf("dev auth provider needs AUTH_DEV_SECRET or AUTH_DEV_PLAIN_TOKENS=true")
+	}
+
+	return p, nil
+}
+
+func (p *DevProvider) VerifyToken(_ context.Context, token string) (VerifiedPrincipal, error) {
+	if strings.HasPrefix(token, DevPlainTokenPrefix) {
+		if !p.allowPlain {
+			return VerifiedPrincipal{}, fmt.Errorf("%w: plain dev tokens are disabled", ErrInvalidToken)
+		}
+		email := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(token, DevPlainTokenPrefix)))
+		if !strings.Contains(email, "@") {
+			return VerifiedPrincipal{}, fmt.Errorf("%w: expected dev:<email>", ErrInvalidToken)
+		}
+		return VerifiedPrincipal{
+			Provider:       authProviderDev,
+			ProviderUserID: email,
+			PrimaryEmail:   email,
+			EmailVerified:  true,
+		}, nil
+	}
+
+	if len(p.secret) == 0 {
+		return VerifiedPrincipal{}, fmt.Errorf("%w: signed dev tokens are disabled", ErrInvalidToken)
+	}
+
+	jwt, err := parseJWT(token)
+	if err != nil {
+		return VerifiedPrincipal{}, err
+	}
+	if jwt.header.Alg != "HS256" {
+		return VerifiedPrincipal{}, fmt.Errorf("%w: dev tokens must be HS256", ErrInvalidToken)
+	}
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write([]byte(jwt.signingInput))
+	if !hmac.Equal(mac.Sum(nil), jwt.signature) {
+		return VerifiedPrincipal{}, fmt.Errorf("%w: bad signature", ErrInvalidToken)
+	}
+
+	var claims oidcClaims
+	if err := json.Unmarshal(jwt.payload, &claims); err != nil {
+		return VerifiedPrincipal{}, fmt.Errorf("%w: parse claims: %v", ErrInvalidToken, err)
+	}
+	if err := validateClaims(claims.jwtClaims, claimExpectations{Issuer: authProviderDev, ClockSkew: devClockSkew}, p.now()); err != nil {
+		return VerifiedPrincipal{}, err
+	}
+
+	return VerifiedPrincipal{
+		Provider:       authProviderDev,
+		ProviderUserID: claims.Subject,
+		PrimaryEmail:   claims.Email,
+		EmailVerified:  bool(claims.EmailVerified),
+		ExpiresAt:      claims.ExpiresAt.Time,
+	}, nil
+}
+
+// SignDevToken mints an HS256 dev token for tests and local tooling. The
+// email is marked verified.
+func SignDevToken(secret string, subject string, email string, ttl time.Duration) (string, error) {
+	if strings.TrimSpace(secret) == "" {
+		return "", fmt.Errorf("missing dev token secret")
+	}
+	if ttl <= 0 {
+		ttl = time.Hour
+	}
+
+	now := time.Now()
+	header, err := json.Marshal(map[string]string{"alg": "HS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(map[string]any{
+		"iss":            authProviderDev,
+		"sub":            subject,
+		"email":          email,
+		"email_verified": true,
+		"iat":            now.Unix(),
+		"exp":            now.Add(ttl).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, []byte(strings.TrimSpace(secret)))
+	mac.Write([]byte(signingInput))
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
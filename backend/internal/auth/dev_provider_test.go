@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewDevProviderRefusesProduction(t *testing.T) {
+	for _, env := range []string{"production", "staging", ""} {
+		if _, err := NewDevProvider(DevConfig{Env: env, Secret: "s"}); err == nil {
+			t.Fatalf("expected %q to be refused", env)
+		}
+	}
+	if _, err := NewDevProvider(DevConfig{Env: "test"}); err == nil {
+		t.Fatalf("expected test env without a secret to be refused")
+	}
+	if _, err := NewDevProvider(DevConfig{Env: "development"}); err == nil {
+		t.Fatalf("expected development without a secret or plain tokens to be refused")
+	}
+	if _, err := NewDevProvider(DevConfig{Env: "test", Secret: "s", AllowPlainTokens: true}); err == nil {
+		t.Fatalf("expected plain tokens outside development to be refused")
+	}
+	if _, err := NewDevProvider(DevConfig{AllowPlainTokens: true}); err == nil {
+		t.Fatalf("expected an unset APP_ENV to be refused")
+	}
+}
+
+func TestDevProviderVerifyToken(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("plain tokens in development", func(t *testing.T) {
+		provider, err := NewDevProvider(DevConfig{Env: "development", AllowPlainTokens: true})
+		if err != nil {
+			t.Fatalf("new provider: %v", err)
+		}
+		principal, err := provider.VerifyToken(ctx, "dev:Alice@Example.com")
+		if err != nil {
+			t.Fatalf("verify: %v", err)
+		}
+		if principal.ProviderUserID != "alice@example.com" || !principal.EmailVerified || principal.Provider != "dev" {
+			t.Fatalf("unexpected principal %+v", principal)
+		}
+	})
+
+	provider, err := NewDevProvider(DevConfig{Env: "test", Secret: "secret"})
+	if err != nil {
+		t.Fatalf("new provider: %v", err)
+	}
+
+	t.Run("plain tokens rejected without opt-in", func(t *testing.T) {
+		provider, err := NewDevProvider(DevConfig{Env: "development", Secret: "secret"})
+		if err != nil {
+			t.Fatalf("new provider: %v", err)
+		}
+		if _, err := provider.VerifyToken(ctx, "dev:alice@example.com"); !errors.Is(err, ErrInvalidToken) {
+			t.Fatalf("expected ErrInvalidToken, got %v", err)
+		}
+	})
+
+	t.Run("plain tokens rejected outside development", func(t *testing.T) {
+		if _, err := provider.VerifyToken(ctx, "dev:alice@example.com"); !errors.Is(err, ErrInvalidToken) {
+			t.Fatalf("expected ErrInvalidToken, got %v", err)
+		}
+	})
+
+	t.Run("signed tokens", func(t *testing.T) {
+		token, err := SignDevToken("secret", "user-1", "bob@example.com", time.Minute)
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		principal, err := provider.VerifyToken(ctx, token)
+		if err != nil {
+			t.Fatalf("verify: %v", err)
+		}
+		if principal.ProviderUserID != "user-1" || principal.PrimaryEmail != "bob@example.com" || principal.ExpiresAt.IsZero() {
+			t.Fatalf("unexpected principal %+v", principal)
+		}
+	})
+
+	t.Run("wrong secret", func(t *testing.T) {
+		token, _ := SignDevToken("other", "user-1", "bob@example.com", time.Minute)
+		if _, err := provider.VerifyToken(ctx, token); !errors.Is(err, ErrInvalidToken) {
+			t.Fatalf("expected ErrInvalidToken, got %v", err)
+		}
+	})
+}
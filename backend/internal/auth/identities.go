@@ -0,0 +1,263 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"saas-core-template/backend/internal/audit"
+	"saas-core-template/backend/internal/db"
+)
+
+var (
+	ErrIdentityNotFound        = errors.New("identity not found")
+	ErrIdentityLinkedElsewhere = errors.New("identity is linked to another user")
+	ErrLastIdentity            = errors.New("cannot unlink the last identity")
+)
+
+type Identity struct {
+	ID             string    `json:"id"`
+	Provider       string    `json:"provider"`
+	ProviderUserID string    `json:"providerUserId"`
+	Email          string    `json:"email,omitempty"`
+	EmailVerified  bool      `json:"emailVerified"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+func (s *Service) ListIdentities(ctx context.Context, userID string) ([]Identity, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT id::text, provider, provider_user_id, COALESCE(provider_email, ''), email_verified_at IS NOT NULL, created_at
+		FROM auth_identities
+		WHERE user_id = $1
+		ORDER BY created_at ASC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list identities: %w", err)
+	}
+	defer rows.Close()
+
+	out := []Identity{}
+	for rows.Next() {
+		var identity Identity
+		if err := rows.Scan(&identity.ID, &identity.Provider, &identity.ProviderUserID, &identity.Email, &identity.EmailVerified, &identity.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan identity: %w", err)
+		}
+		out = append(out, identity)
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("list identities rows: %w", rows.Err())
+	}
+	return out, nil
+}
+
+// LinkIdentity attaches the identity behind token (from any configured
+// provider) to userID. Linking an identity the user already has is a no-op.
+func (s *Service) LinkIdentity(ctx context.Context, userID string, token string) (Identity, error) {
+	principal, err := s.provider.VerifyToken(ctx, token)
+	if err != nil {
+		return Identity{}, fmt.Errorf("verify token: %w", err)
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return Identity{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var ownerID string
+	err = tx.QueryRow(ctx, `
+		SELECT user_id::text
+		FROM auth_identities
+		WHERE provider = $1 AND provider_user_id = $2
+	`, principal.Provider, principal.ProviderUserID).Scan(&ownerID)
+	switch {
+	case err == nil && ownerID != userID:
+		return Identity{}, ErrIdentityLinkedElsewhere
+	case err != nil && !errors.Is(err, pgx.ErrNoRows):
+		return Identity{}, fmt.Errorf("load identity: %w", err)
+	}
+
+	identity, err := upsertIdentity(ctx, tx, userID, principal)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	// Linking by hand undoes an earlier unlink of this provider.
+	if _, err := tx.Exec(ctx, `
+		DELETE FROM auth_identity_unlinks
+		WHERE user_id = $1 AND provider = $2
+	`, userID, principal.Provider); err != nil {
+		return Identity{}, fmt.Errorf("clear identity unlink: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return Identity{}, fmt.Errorf("commit link identity: %w", err)
+	}
+
+	if ownerID == "" {
+		s.recordIdentityLinked(ctx, userID, principal, "manual")
+	}
+
+	return identity, nil
+}
+
+// UnlinkIdentity detaches one of the user's identities. The last identity
+// cannot be removed, since the user could no longer sign in. The provider is
+// remembered so the next sign-in through it is not linked back by verified
+// email, and the user's cached sessions are evicted.
+func (s *Service) UnlinkIdentity(ctx context.Context, userID string, identityID string) error {
+	identityID, ok := db.ParseUUID(identityID)
+	if !ok {
+		return ErrIdentityNotFound
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Lock the user so concurrent unlinks cannot both pass the count check.
+	if _, err := tx.Exec(ctx, `SELECT id FROM users WHERE id = $1 FOR UPDATE`, userID); err != nil {
+		return fmt.Errorf("lock user: %w", err)
+	}
+
+	var count int
+	if err := tx.QueryRow(ctx, `SELECT COUNT(*) FROM auth_identities WHERE user_id = $1`, userID).Scan(&count); err != nil {
+		return fmt.Errorf("count identities: %w", err)
+	}
+
+	var provider, providerUserID string
+	err = tx.QueryRow(ctx, `
+		DELETE FROM auth_identities
+		WHERE user_id = $1 AND id = $2::uuid
+		RETURNING provider, provider_user_id
+	`, userID, identityID).Scan(&provider, &providerUserID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrIdentityNotFound
+		}
+		return fmt.Errorf("delete identity: %w", err)
+	}
+	// Checked after the delete so an unknown ID reports ErrIdentityNotFound;
+	// returning here rolls the delete back.
+	if count <= 1 {
+		return ErrLastIdentity
+	}
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO auth_identity_unlinks (user_id, provider)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id, provider) DO UPDATE
+		SET created_at = now()
+	`, userID, provider); err != nil {
+		return fmt.Errorf("record identity unlink: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit unlink identity: %w", err)
+	}
+
+	s.evictSessions(ctx, userID)
+
+	_ = s.audit.Record(ctx, audit.Event{
+		UserID: userID,
+		Action: "auth_identity_unlinked",
+		Data:   map[string]any{"identity_id": identityID, "provider": provider, "provider_user_id": providerUserID},
+	})
+	return nil
+}
+
+// linksByVerifiedEmail reports whether identities from provider may be linked
+// to an existing user by verified email. Dev tokens claim any email as
+// verified, so dev identities are never linked this way.
+func linksByVerifiedEmail(provider string) bool {
+	return provider != authProviderDev
+}
+
+// userForVerifiedEmail returns the user that already owns a verified identity
+// with email, for a new identity from provider. It only matches when exactly
+// one user does; anything else is ambiguous and gets a new user instead. Dev
+// identities are ignored, and a user who unlinked provider is not matched.
+func userForVerifiedEmail(ctx context.Context, tx pgx.Tx, provider string, email string) (string, bool, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT ai.user_id::text,
+		       EXISTS (
+		           SELECT 1
+		           FROM auth_identity_unlinks ul
+		           WHERE ul.user_id = ai.user_id AND ul.provider = $2
+		       )
+		FROM auth_identities ai
+		WHERE lower(ai.provider_email) = lower($1)
+		  AND ai.email_verified_at IS NOT NULL
+		  AND ai.provider <> $3
+		GROUP BY ai.user_id
+		LIMIT 2
+	`, email, provider, authProviderDev)
+	if err != nil {
+		return "", false, fmt.Errorf("find user by verified email: %w", err)
+	}
+	defer rows.Close()
+
+	var userIDs []string
+	var unlinked bool
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID, &unlinked); err != nil {
+			return "", false, fmt.Errorf("scan user by verified email: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+	if rows.Err() != nil {
+		return "", false, fmt.Errorf("find user by verified email rows: %w", rows.Err())
+	}
+
+	if len(userIDs) != 1 || unlinked {
+		return "", false, nil
+	}
+	return userIDs[0], true, nil
+}
+
+func upsertIdentity(ctx context.Context, tx pgx.Tx, userID string, principal VerifiedPrincipal) (Identity, error) {
+	var identity Identity
+	err := tx.QueryRow(ctx, `
+		INSERT INTO auth_identities (user_id, provider, provider_user_id, provider_email, email_verified_at)
+		VALUES ($1, $2, $3, $4, CASE WHEN $5 THEN now() ELSE NULL END)
+		ON CONFLICT (provider, provider_user_id) DO UPDATE
+		SET provider_email = COALESCE(EXCLUDED.provider_email, auth_identities.provider_email),
+		    email_verified_at = CASE
+		        WHEN EXCLUDED.provider_email IS NOT NULL AND EXCLUDED.provider_email IS DISTINCT FROM auth_identities.provider_email THEN
+		            EXCLUDED.email_verified_at
+		        ELSE COALESCE(auth_identities.email_verified_at, EXCLUDED.email_verified_at)
+		    END,
+		    updated_at = now()
+		WHERE auth_identities.user_id = EXCLUDED.user_id
+		RETURNING id::text, provider, provider_user_id, COALESCE(provider_email, ''), email_verified_at IS NOT NULL, created_at
+	`, userID, principal.Provider, principal.ProviderUserID, db.EmptyToNil(strings.TrimSpace(principal.PrimaryEmail)), principal.EmailVerified).Scan(
+		&identity.ID, &identity.Provider, &identity.ProviderUserID, &identity.Email, &identity.EmailVerified, &identity.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// The conflicting row belongs to someone else.
+			return Identity{}, ErrIdentityLinkedElsewhere
+		}
+		return Identity{}, fmt.Errorf("insert identity: %w", err)
+	}
+	return identity, nil
+}
+
+func (s *Service) recordIdentityLinked(ctx context.Context, userID string, principal VerifiedPrincipal, method string) {
+	_ = s.audit.Record(ctx, audit.Event{
+		UserID: userID,
+		Action: "auth_identity_linked",
+		Data: map[string]any{
+			"provider":         principal.Provider,
+			"provider_user_id": principal.ProviderUserID,
+			"email":            strings.TrimSpace(principal.PrimaryEmail),
+			"method":           method,
+		},
+	})
+}
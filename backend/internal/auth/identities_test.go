@@ -0,0 +1,224 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"saas-core-template/backend/internal/testdb"
+)
+
+func testPrincipal(provider string, subject string, email string, verified bool) VerifiedPrincipal {
+	return VerifiedPrincipal{
+		Provider:       provider,
+		ProviderUserID: subject,
+		PrimaryEmail:   email,
+		EmailVerified:  verified,
+		ExpiresAt:      time.Now().Add(time.Hour),
+	}
+}
+
+// signIn authenticates as principal with a token unique to it.
+func signIn(t *testing.T, svc *Service, provider *staticProvider, principal VerifiedPrincipal) User {
+	t.Helper()
+	provider.principal = principal
+	user, err := svc.Authenticate(context.Background(), principal.Provider+":"+principal.ProviderUserID)
+	if err != nil {
+		t.Fatalf("authenticate %s/%s: %v", principal.Provider, principal.ProviderUserID, err)
+	}
+	return user
+}
+
+func identityFor(t *testing.T, svc *Service, userID string, provider string) Identity {
+	t.Helper()
+	identities, err := svc.ListIdentities(context.Background(), userID)
+	if err != nil {
+		t.Fatalf("list identities: %v", err)
+	}
+	for _, identity := range identities {
+		if identity.Provider == provider {
+			return identity
+		}
+	}
+	t.Fatalf("no %s identity for user %s in %+v", provider, userID, identities)
+	return Identity{}
+}
+
+func TestVerifiedEmailLinking(t *testing.T) {
+	pool := testdb.New(t)
+	provider := &staticProvider{}
+	svc := NewService(provider, pool)
+
+	t.Run("links a new provider by verified email", func(t *testing.T) {
+		first := signIn(t, svc, provider, testPrincipal("clerk", "c1", "alice@example.com", true))
+		second := signIn(t, svc, provider, testPrincipal("oidc", "o1", "Alice@Example.com", true))
+		if second.ID != first.ID {
+			t.Fatalf("expected the oidc identity to link to %s, got %s", first.ID, second.ID)
+		}
+	})
+
+	t.Run("does not link unverified emails", func(t *testing.T) {
+		first := signIn(t, svc, provider, testPrincipal("clerk", "c2", "bob@example.com", true))
+		second := signIn(t, svc, provider, testPrincipal("oidc", "o2", "bob@example.com", false))
+		if second.ID == first.ID {
+			t.Fatalf("expected an unverified email to get a new user")
+		}
+	})
+
+	t.Run("dev identities never link", func(t *testing.T) {
+		owner := signIn(t, svc, provider, testPrincipal("clerk", "c3", "carol@example.com", true))
+		dev := signIn(t, svc, provider, testPrincipal("dev", "carol@example.com", "carol@example.com", true))
+		if dev.ID == owner.ID {
+			t.Fatalf("expected a dev identity not to link to an existing user")
+		}
+
+		devFirst := signIn(t, svc, provider, testPrincipal("dev", "dave@example.com", "dave@example.com", true))
+		clerkUser := signIn(t, svc, provider, testPrincipal("clerk", "c4", "dave@example.com", true))
+		if clerkUser.ID == devFirst.ID {
+			t.Fatalf("expected a real identity not to link to a dev user")
+		}
+	})
+
+	t.Run("changed email drops verification", func(t *testing.T) {
+		owner := signIn(t, svc, provider, testPrincipal("clerk", "c5", "erin@example.com", true))
+
+		// The provider now reports an address it has not verified.
+		signIn(t, svc, provider, testPrincipal("clerk", "c5", "mallory@example.com", false))
+		if identity := identityFor(t, svc, owner.ID, "clerk"); identity.Email != "mallory@example.com" || identity.EmailVerified {
+			t.Fatalf("expected the new email to be unverified, got %+v", identity)
+		}
+
+		attacker := signIn(t, svc, provider, testPrincipal("oidc", "o5", "mallory@example.com", true))
+		if attacker.ID == owner.ID {
+			t.Fatalf("expected an unverified email change not to allow linking")
+		}
+
+		// Verification of an unchanged email is kept.
+		signIn(t, svc, provider, testPrincipal("clerk", "c5", "mallory@example.com", true))
+		signIn(t, svc, provider, testPrincipal("clerk", "c5", "mallory@example.com", false))
+		if identity := identityFor(t, svc, owner.ID, "clerk"); !identity.EmailVerified {
+			t.Fatalf("expected verification of an unchanged email to be kept, got %+v", identity)
+		}
+	})
+
+	t.Run("only the first identity updates the primary email", func(t *testing.T) {
+		ctx := context.Background()
+		owner := signIn(t, svc, provider, testPrincipal("clerk", "c7", "heidi@example.com", true))
+		signIn(t, svc, provider, testPrincipal("oidc", "o7", "HEIDI@example.com", true))
+
+		primaryEmail := func() string {
+			t.Helper()
+			var email string
+			if err := pool.QueryRow(ctx, `SELECT primary_email FROM users WHERE id = $1`, owner.ID).Scan(&email); err != nil {
+				t.Fatalf("load user: %v", err)
+			}
+			return email
+		}
+
+		// The linked provider later reports a secondary address.
+		signIn(t, svc, provider, testPrincipal("oidc", "o7", "heidi.work@example.com", true))
+		if got := primaryEmail(); got != "heidi@example.com" {
+			t.Fatalf("expected a linked identity not to change the primary email, got %q", got)
+		}
+
+		// A case-only difference is not a change.
+		signIn(t, svc, provider, testPrincipal("clerk", "c7", "Heidi@Example.com", true))
+		if got := primaryEmail(); got != "heidi@example.com" {
+			t.Fatalf("expected a case-only difference to be ignored, got %q", got)
+		}
+
+		signIn(t, svc, provider, testPrincipal("clerk", "c7", "heidi@new.example.com", true))
+		if got := primaryEmail(); got != "heidi@new.example.com" {
+			t.Fatalf("expected the first identity to update the primary email, got %q", got)
+		}
+	})
+
+	t.Run("changed email on manual link drops verification", func(t *testing.T) {
+		ctx := context.Background()
+		owner := signIn(t, svc, provider, testPrincipal("clerk", "c6", "frank@example.com", true))
+
+		provider.principal = testPrincipal("oidc", "o6", "frank@example.com", true)
+		if identity, err := svc.LinkIdentity(ctx, owner.ID, "link"); err != nil || !identity.EmailVerified {
+			t.Fatalf("expected a verified linked identity, got %+v, %v", identity, err)
+		}
+
+		provider.principal = testPrincipal("oidc", "o6", "grace@example.com", false)
+		identity, err := svc.LinkIdentity(ctx, owner.ID, "link")
+		if err != nil {
+			t.Fatalf("relink: %v", err)
+		}
+		if identity.Email != "grace@example.com" || identity.EmailVerified {
+			t.Fatalf("expected the new email to be unverified, got %+v", identity)
+		}
+	})
+}
+
+func TestLinkAndUnlinkIdentity(t *testing.T) {
+	ctx := context.Background()
+	pool := testdb.New(t)
+	provider := &staticProvider{}
+	cache := memorySessionCache{}
+	svc := NewService(provider, pool, WithSessionCache(cache, time.Minute))
+
+	owner := signIn(t, svc, provider, testPrincipal("clerk", "c1", "alice@example.com", true))
+
+	provider.principal = testPrincipal("oidc", "o1", "alice@example.com", true)
+	linked, err := svc.LinkIdentity(ctx, owner.ID, "link")
+	if err != nil {
+		t.Fatalf("link: %v", err)
+	}
+	if linked.Provider != "oidc" || linked.ProviderUserID != "o1" {
+		t.Fatalf("unexpected linked identity %+v", linked)
+	}
+	if again, err := svc.LinkIdentity(ctx, owner.ID, "link"); err != nil || again.ID != linked.ID {
+		t.Fatalf("expected relinking to be a no-op, got %+v, %v", again, err)
+	}
+
+	other := signIn(t, svc, provider, testPrincipal("clerk", "c2", "bob@example.com", true))
+	provider.principal = testPrincipal("oidc", "o1", "alice@example.com", true)
+	if _, err := svc.LinkIdentity(ctx, other.ID, "link"); !errors.Is(err, ErrIdentityLinkedElsewhere) {
+		t.Fatalf("expected ErrIdentityLinkedElsewhere, got %v", err)
+	}
+
+	// Sign in through the linked identity so a session is cached.
+	if user := signIn(t, svc, provider, testPrincipal("oidc", "o1", "alice@example.com", true)); user.ID != owner.ID {
+		t.Fatalf("expected the linked identity to sign in as %s, got %s", owner.ID, user.ID)
+	}
+	if _, ok := svc.cachedUser(ctx, "oidc:o1"); !ok {
+		t.Fatalf("expected the session to be cached")
+	}
+
+	if err := svc.UnlinkIdentity(ctx, owner.ID, "00000000-0000-0000-0000-000000000000"); !errors.Is(err, ErrIdentityNotFound) {
+		t.Fatalf("expected ErrIdentityNotFound, got %v", err)
+	}
+	if err := svc.UnlinkIdentity(ctx, owner.ID, linked.ID); err != nil {
+		t.Fatalf("unlink: %v", err)
+	}
+
+	if _, ok := svc.cachedUser(ctx, "oidc:o1"); ok {
+		t.Fatalf("expected unlinking to evict cached sessions")
+	}
+
+	// The unlinked provider must not be linked back by verified email.
+	if user := signIn(t, svc, provider, testPrincipal("oidc", "o1", "alice@example.com", true)); user.ID == owner.ID {
+		t.Fatalf("expected the unlinked identity to get a new user")
+	}
+
+	if err := svc.UnlinkIdentity(ctx, owner.ID, identityFor(t, svc, owner.ID, "clerk").ID); !errors.Is(err, ErrLastIdentity) {
+		t.Fatalf("expected ErrLastIdentity, got %v", err)
+	}
+
+	// Linking the provider again by hand lifts the exclusion.
+	provider.principal = testPrincipal("oidc", "o2", "alice@example.com", true)
+	if _, err := svc.LinkIdentity(ctx, owner.ID, "link"); err != nil {
+		t.Fatalf("link again: %v", err)
+	}
+	var unlinks int
+	if err := pool.QueryRow(ctx, `SELECT COUNT(*) FROM auth_identity_unlinks WHERE user_id = $1`, owner.ID).Scan(&unlinks); err != nil {
+		t.Fatalf("count unlinks: %v", err)
+	}
+	if unlinks != 0 {
+		t.Fatalf("expected the unlink record to be cleared, got %d", unlinks)
+	}
+}
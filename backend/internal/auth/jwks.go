@@ -0,0 +1,255 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultJWKSCacheTTL   = time.Hour
+	defaultJWKSMinRefresh = time.Minute
+)
+
+var ErrUnknownSigningKey = errors.New("unknown signing key")
+
+// jwksCache holds the public keys published at a JWKS endpoint. Keys are
+// refetched when they are older than ttl, or when a token names a kid we have
+// not seen (key rotation), but never more than once per minRefresh so a flood
+// of tokens with bogus kids cannot hammer the identity provider. The fetch runs
+// without holding mu, so tokens signed by cached keys verify while it is in
+// flight; only callers that need the new set wait for it.
+type jwksCache struct {
+	url        string
+	header     http.Header
+	client     *http.Client
+	ttl        time.Duration
+	minRefresh time.Duration
+	now        func() time.Time
+
+	// resolveURL, when set, is called once to discover url lazily.
+	resolveURL func(ctx context.Context) (string, error)
+
+	mu          sync.Mutex
+	keys        map[string]crypto.PublicKey
+	fetchedAt   time.Time
+	attemptedAt time.Time
+	// refreshing is closed when the fetch in flight finishes; nil when idle.
+	refreshing chan struct{}
+	refreshErr error
+}
+
+func newJWKSCache(url string, client *http.Client) *jwksCache {
+	return &jwksCache{
+		url:        strings.TrimSpace(url),
+		client:     client,
+		ttl:        defaultJWKSCacheTTL,
+		minRefresh: defaultJWKSMinRefresh,
+		now:        time.Now,
+	}
+}
+
+// key returns the public key for kid. An empty kid is accepted only when the
+// set holds exactly one key.
+func (c *jwksCache) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	c.mu.Lock()
+	now := c.now()
+	stale := c.keys == nil || now.Sub(c.fetchedAt) >= c.ttl
+	key, found := c.lookup(kid)
+	if found && !stale {
+		c.mu.Unlock()
+		return key, nil
+	}
+
+	done := c.refreshing
+	if done == nil && now.Sub(c.attemptedAt) >= c.minRefresh {
+		c.attemptedAt = now
+		done = make(chan struct{})
+		c.refreshing = done
+		// The fetch outlives a caller that gives up, so the callers waiting on
+		// it are not failed by someone else's cancelled request.
+		go c.refresh(context.WithoutCancel(ctx), c.url, now, done)
+	}
+	c.mu.Unlock()
+
+	// A stale but known key stays valid while the set is refetched in the
+	// background, and the provider being briefly unreachable should not log
+	// everyone out. Only an unknown kid waits for the fetch.
+	if found {
+		return key, nil
+	}
+	if done == nil {
+		return nil, fmt.Errorf("%w: kid %q", ErrUnknownSigningKey, kid)
+	}
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if fresh, ok := c.lookup(kid); ok {
+		return fresh, nil
+	}
+	if c.refreshErr != nil {
+		return nil, c.refreshErr
+	}
+	return nil, fmt.Errorf("%w: kid %q", ErrUnknownSigningKey, kid)
+}
+
+func (c *jwksCache) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" {
+		if len(c.keys) != 1 {
+			return nil, false
+		}
+		for _, key := range c.keys {
+			return key, true
+		}
+	}
+	key, ok := c.keys[kid]
+	return key, ok
+}
+
+// refresh fetches the key set, publishes the result and closes done.
+func (c *jwksCache) refresh(ctx context.Context, url string, startedAt time.Time, done chan struct{}) {
+	keys, url, err := c.fetch(ctx, url)
+
+	c.mu.Lock()
+	c.url = url
+	c.refreshErr = err
+	if err == nil {
+		c.keys = keys
+		c.fetchedAt = startedAt
+	}
+	c.refreshing = nil
+	c.mu.Unlock()
+	close(done)
+}
+
+func (c *jwksCache) fetch(ctx context.Context, url string) (map[string]crypto.PublicKey, string, error) {
+	if url == "" && c.resolveURL != nil {
+		resolved, err := c.resolveURL(ctx)
+		if err != nil {
+			return nil, url, err
+		}
+		url = resolved
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := getJSON(ctx, c.client, url, c.header, &set); err != nil {
+		return nil, url, fmt.Errorf("fetch jwks: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			// One malformed or unsupported key should not take the others down.
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, url, fmt.Errorf("fetch jwks: no usable signing keys at %s", url)
+	}
+	return keys, url, nil
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("decode n: %w", err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("decode e: %w", err)
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if len(n) < 256 || !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("unsupported rsa key parameters")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("decode x: %w", err)
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("decode y: %w", err)
+		}
+		if len(x) != 32 || len(y) != 32 {
+			return nil, fmt.Errorf("invalid P-256 coordinates")
+		}
+		// crypto/ecdh rejects points that are not on the curve.
+		if _, err := ecdh.P256().NewPublicKey(append(append([]byte{4}, x...), y...)); err != nil {
+			return nil, fmt.Errorf("invalid P-256 point: %w", err)
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func getJSON(ctx context.Context, client *http.Client, url string, header http.Header, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("call %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		responseBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s status %d: %s", url, resp.StatusCode, string(responseBody))
+	}
+
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(out); err != nil {
+		return fmt.Errorf("decode %s: %w", url, err)
+	}
+	return nil
+}
@@ -0,0 +1,222 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrTokenExpired = errors.New("token expired")
+)
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Typ string `json:"typ"`
+}
+
+// jwtClaims holds the registered claims checked during verification. Other
+// claims are read from the raw payload by the provider that needs them.
+type jwtClaims struct {
+	Issuer    string       `json:"iss"`
+	Subject   string       `json:"sub"`
+	Audience  audience     `json:"aud"`
+	ExpiresAt *numericDate `json:"exp"`
+	NotBefore *numericDate `json:"nbf"`
+	IssuedAt  *numericDate `json:"iat"`
+}
+
+func (c jwtClaims) registered() jwtClaims {
+	return c
+}
+
+// audience accepts both forms allowed by RFC 7519: a string or an array of strings.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return fmt.Errorf("aud must be a string or an array of strings")
+	}
+	*a = many
+	return nil
+}
+
+func (a audience) contains(value string) bool {
+	for _, v := range a {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// numericDate is a JWT timestamp in (possibly fractional) seconds since the epoch.
+type numericDate struct {
+	time.Time
+}
+
+func (d *numericDate) UnmarshalJSON(data []byte) error {
+	var seconds json.Number
+	if err := json.Unmarshal(data, &seconds); err != nil {
+		return fmt.Errorf("invalid numeric date: %w", err)
+	}
+	value, err := seconds.Float64()
+	if err != nil {
+		return fmt.Errorf("invalid numeric date: %w", err)
+	}
+	d.Time = time.Unix(0, int64(value*float64(time.Second))).UTC()
+	return nil
+}
+
+// parsedJWT is a compact-serialized JWS split into its parts. Nothing in it is
+// trusted until verifySignature succeeds.
+type parsedJWT struct {
+	header       jwtHeader
+	payload      []byte
+	signingInput string
+	signature    []byte
+}
+
+func parseJWT(token string) (parsedJWT, error) {
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 3 {
+		return parsedJWT{}, fmt.Errorf("%w: expected three segments", ErrInvalidToken)
+	}
+
+	rawHeader, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return parsedJWT{}, fmt.Errorf("%w: decode header: %v", ErrInvalidToken, err)
+	}
+	var header jwtHeader
+	if err := json.Unmarshal(rawHeader, &header); err != nil {
+		return parsedJWT{}, fmt.Errorf("%w: parse header: %v", ErrInvalidToken, err)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return parsedJWT{}, fmt.Errorf("%w: decode payload: %v", ErrInvalidToken, err)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return parsedJWT{}, fmt.Errorf("%w: decode signature: %v", ErrInvalidToken, err)
+	}
+
+	return parsedJWT{
+		header:       header,
+		payload:      payload,
+		signingInput: parts[0] + "." + parts[1],
+		signature:    signature,
+	}, nil
+}
+
+// verifySignature checks the JWS signature with key. Only RS256 and ES256 are
+// accepted; in particular "none" and HMAC algorithms are rejected so a public
+// key can never be used as a shared secret.
+func verifySignature(jwt parsedJWT, key crypto.PublicKey) error {
+	digest := sha256.Sum256([]byte(jwt.signingInput))
+
+	switch jwt.header.Alg {
+	case "RS256":
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: RS256 token signed with a non-RSA key", ErrInvalidToken)
+		}
+		if err := rsa.VerifyPKCS1v15(rsaKey, crypto.SHA256, digest[:], jwt.signature); err != nil {
+			return fmt.Errorf("%w: bad signature", ErrInvalidToken)
+		}
+		return nil
+	case "ES256":
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok || ecKey.Curve.Params().Name != "P-256" {
+			return fmt.Errorf("%w: ES256 token signed with a non-P-256 key", ErrInvalidToken)
+		}
+		// JWS encodes ECDSA signatures as fixed-width r || s, not ASN.1.
+		if len(jwt.signature) != 64 {
+			return fmt.Errorf("%w: bad signature length", ErrInvalidToken)
+		}
+		r := new(big.Int).SetBytes(jwt.signature[:32])
+		s := new(big.Int).SetBytes(jwt.signature[32:])
+		if !ecdsa.Verify(ecKey, digest[:], r, s) {
+			return fmt.Errorf("%w: bad signature", ErrInvalidToken)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: unsupported alg %q", ErrInvalidToken, jwt.header.Alg)
+	}
+}
+
+// claimExpectations are the checks validateClaims applies. An empty Audience
+// skips the aud check.
+type claimExpectations struct {
+	Issuer    string
+	Audience  string
+	ClockSkew time.Duration
+}
+
+func validateClaims(claims jwtClaims, want claimExpectations, now time.Time) error {
+	if want.Issuer != "" && claims.Issuer != want.Issuer {
+		return fmt.Errorf("%w: unexpected issuer %q", ErrInvalidToken, claims.Issuer)
+	}
+	if want.Audience != "" && !claims.Audience.contains(want.Audience) {
+		return fmt.Errorf("%w: token not issued for this audience", ErrInvalidToken)
+	}
+	if strings.TrimSpace(claims.Subject) == "" {
+		return fmt.Errorf("%w: missing sub", ErrInvalidToken)
+	}
+
+	if claims.ExpiresAt == nil {
+		return fmt.Errorf("%w: missing exp", ErrInvalidToken)
+	}
+	if !now.Before(claims.ExpiresAt.Add(want.ClockSkew)) {
+		return ErrTokenExpired
+	}
+	if claims.NotBefore != nil && now.Add(want.ClockSkew).Before(claims.NotBefore.Time) {
+		return fmt.Errorf("%w: token not valid yet", ErrInvalidToken)
+	}
+	if claims.IssuedAt != nil && now.Add(want.ClockSkew).Before(claims.IssuedAt.Time) {
+		return fmt.Errorf("%w: token issued in the future", ErrInvalidToken)
+	}
+	return nil
+}
+
+// verifyJWT checks token's signature against keys and its registered claims
+// against want, then decodes the full claim set into claims. Types passed as
+// claims embed jwtClaims and add the provider-specific fields they map.
+func verifyJWT(ctx context.Context, keys *jwksCache, token string, want claimExpectations, now time.Time, claims interface{ registered() jwtClaims }) error {
+	jwt, err := parseJWT(token)
+	if err != nil {
+		return err
+	}
+
+	key, err := keys.key(ctx, jwt.header.Kid)
+	if err != nil {
+		return err
+	}
+	if err := verifySignature(jwt, key); err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(jwt.payload, claims); err != nil {
+		return fmt.Errorf("%w: parse claims: %v", ErrInvalidToken, err)
+	}
+	return validateClaims(claims.registered(), want, now)
+}
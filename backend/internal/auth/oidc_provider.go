@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	authProviderOIDC        = "oidc"
+	defaultOIDCClockSkew    = 60 * time.Second
+	openIDConfigurationPath = "/.well-known/openid-configuration"
+)
+
+type OIDCConfig struct {
+	// Issuer must match the token's iss claim exactly.
+	Issuer string
+	// Audience must appear in the token's aud claim. It is required: without it
+	// the provider would accept tokens the issuer minted for any other client.
+	// Clerk session tokens carry no aud; use ClerkProvider for those.
+	Audience string
+	// JWKSURL defaults to the jwks_uri advertised by the issuer's discovery document.
+	JWKSURL string
+	// ClockSkew is the leeway applied to exp, nbf and iat. Defaults to 60s.
+	ClockSkew time.Duration
+	// ProviderName is stored as auth_identities.provider. Defaults to "oidc";
+	// keep it stable once users have signed in.
+	ProviderName string
+}
+
+// OIDCProvider verifies RS256/ES256 JWTs locally against the issuer's JWKS, so
+// authenticating a request needs no network round-trip once keys are cached.
+type OIDCProvider struct {
+	name   string
+	want   claimExpectations
+	keys   *jwksCache
+	client *http.Client
+	now    func() time.Time
+}
+
+func NewOIDCProvider(cfg OIDCConfig) (*OIDCProvider, error) {
+	issuer := strings.TrimSpace(cfg.Issuer)
+	if issuer == "" {
+		return nil, fmt.Errorf("oidc issuer is required")
+	}
+	audience := strings.TrimSpace(cfg.Audience)
+	if audience == "" {
+		return nil, fmt.Errorf("oidc audience is required")
+	}
+
+	name := strings.TrimSpace(cfg.ProviderName)
+	if name == "" {
+		name = authProviderOIDC
+	}
+
+	skew := cfg.ClockSkew
+	if skew <= 0 {
+		skew = defaultOIDCClockSkew
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	p := &OIDCProvider{
+		name: name,
+		want: claimExpectations{
+			Issuer:    issuer,
+			Audience:  audience,
+			ClockSkew: skew,
+		},
+		keys:   newJWKSCache(cfg.JWKSURL, client),
+		client: client,
+		now:    time.Now,
+	}
+	p.keys.resolveURL = p.discoverJWKSURL
+
+	return p, nil
+}
+
+func (p *OIDCProvider) VerifyToken(ctx context.Context, token string) (VerifiedPrincipal, error) {
+	claims, err := p.verify(ctx, token)
+	if err != nil {
+		return VerifiedPrincipal{}, err
+	}
+
+	return VerifiedPrincipal{
+		Provider:       p.name,
+		ProviderUserID: claims.Subject,
+		PrimaryEmail:   claims.Email,
+		EmailVerified:  bool(claims.EmailVerified),
+		ExpiresAt:      claims.ExpiresAt.Time,
+	}, nil
+}
+
+type oidcClaims struct {
+	jwtClaims
+	Email         string   `json:"email"`
+	EmailVerified flexBool `json:"email_verified"`
+}
+
+func (p *OIDCProvider) verify(ctx context.Context, token string) (oidcClaims, error) {
+	var claims oidcClaims
+	if err := verifyJWT(ctx, p.keys, token, p.want, p.now(), &claims); err != nil {
+		return oidcClaims{}, err
+	}
+	return claims, nil
+}
+
+func (p *OIDCProvider) discoverJWKSURL(ctx context.Context) (string, error) {
+	var document struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}
+	url := strings.TrimRight(p.want.Issuer, "/") + openIDConfigurationPath
+	if err := getJSON(ctx, p.client, url, nil, &document); err != nil {
+		return "", fmt.Errorf("oidc discovery: %w", err)
+	}
+	// The document must describe the configured issuer, or its keys could
+	// belong to someone else.
+	if document.Issuer != p.want.Issuer {
+		return "", fmt.Errorf("oidc discovery: issuer %q does not match configured issuer %q", document.Issuer, p.want.Issuer)
+	}
+	if strings.TrimSpace(document.JWKSURI) == "" {
+		return "", fmt.Errorf("oidc discovery: %s has no jwks_uri", url)
+	}
+	return document.JWKSURI, nil
+}
+
+// flexBool accepts true/false as JSON booleans or strings; some providers
+// (older Cognito pools among them) send email_verified as "true".
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	var value bool
+	if err := json.Unmarshal(data, &value); err == nil {
+		*b = flexBool(value)
+		return nil
+	}
+
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("expected boolean, got %s", string(data))
+	}
+	*b = flexBool(strings.EqualFold(text, "true"))
+	return nil
+}
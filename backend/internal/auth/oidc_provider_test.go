@@ -0,0 +1,311 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testKey struct {
+	kid string
+	alg string
+	rsa *rsa.PrivateKey
+	ec  *ecdsa.PrivateKey
+}
+
+func newRSAKey(t *testing.T, kid string) testKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate rsa key: %v", err)
+	}
+	return testKey{kid: kid, alg: "RS256", rsa: key}
+}
+
+func newECKey(t *testing.T, kid string) testKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate ec key: %v", err)
+	}
+	return testKey{kid: kid, alg: "ES256", ec: key}
+}
+
+func (k testKey) jwk() map[string]string {
+	b64 := base64.RawURLEncoding.EncodeToString
+	if k.rsa != nil {
+		return map[string]string{
+			"kty": "RSA", "kid": k.kid, "use": "sig",
+			"n": b64(k.rsa.N.Bytes()), "e": b64(big.NewInt(int64(k.rsa.E)).Bytes()),
+		}
+	}
+	return map[string]string{
+		"kty": "EC", "kid": k.kid, "crv": "P-256",
+		"x": b64(k.ec.X.FillBytes(make([]byte, 32))), "y": b64(k.ec.Y.FillBytes(make([]byte, 32))),
+	}
+}
+
+func (k testKey) sign(t *testing.T, claims map[string]any) string {
+	t.Helper()
+	b64 := base64.RawURLEncoding.EncodeToString
+	header, _ := json.Marshal(map[string]string{"alg": k.alg, "kid": k.kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signingInput := b64(header) + "." + b64(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+
+	var signature []byte
+	if k.rsa != nil {
+		sig, err := rsa.SignPKCS1v15(rand.Reader, k.rsa, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		signature = sig
+	} else {
+		r, s, err := ecdsa.Sign(rand.Reader, k.ec, digest[:])
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	return signingInput + "." + b64(signature)
+}
+
+// jwksServer serves discovery and the given keys; fetches counts JWKS requests.
+func jwksServer(t *testing.T, fetches *atomic.Int32, keys ...*testKey) *httptest.Server {
+	t.Helper()
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"issuer": server.URL, "jwks_uri": server.URL + "/jwks"})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		set := []map[string]string{}
+		for _, k := range keys {
+			set = append(set, k.jwk())
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": set})
+	})
+	server = httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestOIDCProviderVerifyToken(t *testing.T) {
+	rsaKey := newRSAKey(t, "rsa-1")
+	ecKey := newECKey(t, "ec-1")
+	var fetches atomic.Int32
+	server := jwksServer(t, &fetches, &rsaKey, &ecKey)
+
+	now := time.Now()
+	provider, err := NewOIDCProvider(OIDCConfig{Issuer: server.URL, Audience: "api", ProviderName: "keycloak"})
+	if err != nil {
+		t.Fatalf("new provider: %v", err)
+	}
+
+	claims := func(overrides map[string]any) map[string]any {
+		c := map[string]any{
+			"iss": server.URL, "aud": []string{"api", "other"}, "sub": "user-1",
+			"email": "a@example.com", "email_verified": "true",
+			"iat": now.Unix(), "exp": now.Add(time.Minute).Unix(),
+		}
+		for k, v := range overrides {
+			c[k] = v
+		}
+		return c
+	}
+
+	t.Run("maps standard claims", func(t *testing.T) {
+		principal, err := provider.VerifyToken(context.Background(), rsaKey.sign(t, claims(nil)))
+		if err != nil {
+			t.Fatalf("verify: %v", err)
+		}
+		want := VerifiedPrincipal{Provider: "keycloak", ProviderUserID: "user-1", PrimaryEmail: "a@example.com", EmailVerified: true, ExpiresAt: time.Unix(now.Add(time.Minute).Unix(), 0).UTC()}
+		if principal != want {
+			t.Fatalf("expected %+v, got %+v", want, principal)
+		}
+	})
+
+	t.Run("accepts ES256", func(t *testing.T) {
+		if _, err := provider.VerifyToken(context.Background(), ecKey.sign(t, claims(map[string]any{"aud": "api"}))); err != nil {
+			t.Fatalf("verify: %v", err)
+		}
+	})
+
+	t.Run("tolerates expiry within clock skew", func(t *testing.T) {
+		token := rsaKey.sign(t, claims(map[string]any{"exp": now.Add(-30 * time.Second).Unix()}))
+		if _, err := provider.VerifyToken(context.Background(), token); err != nil {
+			t.Fatalf("verify: %v", err)
+		}
+	})
+
+	rejected := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"expired", rsaKey.sign(t, claims(map[string]any{"exp": now.Add(-2 * time.Minute).Unix()})), ErrTokenExpired},
+		{"not yet valid", rsaKey.sign(t, claims(map[string]any{"nbf": now.Add(5 * time.Minute).Unix()})), ErrInvalidToken},
+		{"wrong issuer", rsaKey.sign(t, claims(map[string]any{"iss": "https://evil.example"})), ErrInvalidToken},
+		{"wrong audience", rsaKey.sign(t, claims(map[string]any{"aud": "other"})), ErrInvalidToken},
+		{"missing sub", rsaKey.sign(t, claims(map[string]any{"sub": ""})), ErrInvalidToken},
+		{"alg none", noneToken(claims(nil)), ErrInvalidToken},
+		{"tampered", tamper(rsaKey.sign(t, claims(nil))), ErrInvalidToken},
+		{"unknown kid", func() string { k := newRSAKey(t, "rsa-2"); return k.sign(t, claims(nil)) }(), ErrUnknownSigningKey},
+	}
+	for _, tc := range rejected {
+		t.Run("rejects "+tc.name, func(t *testing.T) {
+			_, err := provider.VerifyToken(context.Background(), tc.token)
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, err)
+			}
+		})
+	}
+
+	if got := fetches.Load(); got != 1 {
+		t.Fatalf("expected keys fetched once (unknown kid refresh is rate limited), got %d", got)
+	}
+}
+
+func TestNewOIDCProviderRequiresIssuerAndAudience(t *testing.T) {
+	if _, err := NewOIDCProvider(OIDCConfig{Audience: "api"}); err == nil {
+		t.Fatalf("expected a missing issuer to be refused")
+	}
+	if _, err := NewOIDCProvider(OIDCConfig{Issuer: "https://issuer.example", Audience: " "}); err == nil {
+		t.Fatalf("expected a missing audience to be refused")
+	}
+}
+
+func TestOIDCDiscoveryRejectsIssuerMismatch(t *testing.T) {
+	var fetches atomic.Int32
+	server := jwksServer(t, &fetches)
+
+	provider, err := NewOIDCProvider(OIDCConfig{Issuer: server.URL, Audience: "api"})
+	if err != nil {
+		t.Fatalf("new provider: %v", err)
+	}
+	if _, err := provider.discoverJWKSURL(context.Background()); err != nil {
+		t.Fatalf("discover: %v", err)
+	}
+
+	// The discovery URL ignores the trailing slash, but the issuer must match exactly.
+	provider, err = NewOIDCProvider(OIDCConfig{Issuer: server.URL + "/", Audience: "api"})
+	if err != nil {
+		t.Fatalf("new provider: %v", err)
+	}
+	if _, err := provider.discoverJWKSURL(context.Background()); err == nil {
+		t.Fatalf("expected a discovery document for another issuer to be rejected")
+	}
+}
+
+func TestJWKSCacheRefreshesOnRotation(t *testing.T) {
+	oldKey := newRSAKey(t, "old")
+	var fetches atomic.Int32
+	server := jwksServer(t, &fetches, &oldKey)
+
+	clock := time.Now()
+	cache := newJWKSCache(server.URL+"/jwks", server.Client())
+	cache.now = func() time.Time { return clock }
+
+	if _, err := cache.key(context.Background(), "old"); err != nil {
+		t.Fatalf("key: %v", err)
+	}
+
+	// Rotate: the server now publishes a different key under a new kid.
+	oldKey.kid = "new"
+	clock = clock.Add(2 * defaultJWKSMinRefresh)
+	if _, err := cache.key(context.Background(), "new"); err != nil {
+		t.Fatalf("key after rotation: %v", err)
+	}
+	if got := fetches.Load(); got != 2 {
+		t.Fatalf("expected 2 fetches, got %d", got)
+	}
+}
+
+func TestJWKSCacheFetchDoesNotBlockCachedKeys(t *testing.T) {
+	key := newRSAKey(t, "known")
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		if fetches.Add(1) > 1 {
+			<-release
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{key.jwk()}})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	// Close before server.Close, which waits for the blocked handler.
+	t.Cleanup(func() { close(release) })
+
+	clock := time.Now()
+	cache := newJWKSCache(server.URL+"/jwks", server.Client())
+	cache.now = func() time.Time { return clock }
+	if _, err := cache.key(context.Background(), "known"); err != nil {
+		t.Fatalf("key: %v", err)
+	}
+
+	// Unknown kids start one fetch that hangs; their callers wait for it.
+	clock = clock.Add(2 * defaultJWKSMinRefresh)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	waiting := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := cache.key(ctx, "bogus")
+			waiting <- err
+		}()
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for fetches.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	start := time.Now()
+	if _, err := cache.key(context.Background(), "known"); err != nil {
+		t.Fatalf("cached key during fetch: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected a cached key to resolve without waiting for the fetch, took %s", elapsed)
+	}
+
+	cancel()
+	for i := 0; i < 2; i++ {
+		if err := <-waiting; !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected waiting callers to give up with their context, got %v", err)
+		}
+	}
+	if got := fetches.Load(); got != 2 {
+		t.Fatalf("expected unknown kids to share one fetch, got %d fetches", got)
+	}
+}
+
+func noneToken(claims map[string]any) string {
+	b64 := base64.RawURLEncoding.EncodeToString
+	header, _ := json.Marshal(map[string]string{"alg": "none", "kid": "rsa-1"})
+	payload, _ := json.Marshal(claims)
+	return b64(header) + "." + b64(payload) + "."
+}
+
+func tamper(token string) string {
+	b := []byte(token)
+	if b[len(b)-2] == 'A' {
+		b[len(b)-2] = 'B'
+	} else {
+		b[len(b)-2] = 'A'
+	}
+	return string(b)
+}
@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"context"
+	"errors"
+)
+
+// ChainProvider tries each provider in order and returns the first principal
+// verified. It lets a deployment accept tokens from several identity providers,
+// for example while migrating users from one to another.
+type ChainProvider struct {
+	providers []Provider
+}
+
+func NewChainProvider(providers ...Provider) *ChainProvider {
+	chain := &ChainProvider{}
+	for _, provider := range providers {
+		if provider != nil {
+			chain.providers = append(chain.providers, provider)
+		}
+	}
+	return chain
+}
+
+func (c *ChainProvider) VerifyToken(ctx context.Context, token string) (VerifiedPrincipal, error) {
+	if len(c.providers) == 0 {
+		return VerifiedPrincipal{}, ErrUnauthorized
+	}
+
+	var errs []error
+	for _, provider := range c.providers {
+		principal, err := provider.VerifyToken(ctx, token)
+		if err == nil {
+			return principal, nil
+		}
+		errs = append(errs, err)
+	}
+	return VerifiedPrincipal{}, errors.Join(errs...)
+}
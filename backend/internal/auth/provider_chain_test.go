@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type staticProvider struct {
+	principal VerifiedPrincipal
+	err       error
+	calls     int
+}
+
+func (p *staticProvider) VerifyToken(context.Context, string) (VerifiedPrincipal, error) {
+	p.calls++
+	return p.principal, p.err
+}
+
+func TestChainProvider(t *testing.T) {
+	t.Run("returns the first verified principal", func(t *testing.T) {
+		first := &staticProvider{err: ErrInvalidToken}
+		second := &staticProvider{principal: VerifiedPrincipal{Provider: "oidc", ProviderUserID: "u1"}}
+		third := &staticProvider{principal: VerifiedPrincipal{Provider: "dev"}}
+
+		principal, err := NewChainProvider(first, nil, second, third).VerifyToken(context.Background(), "t")
+		if err != nil {
+			t.Fatalf("verify: %v", err)
+		}
+		if principal.Provider != "oidc" || third.calls != 0 {
+			t.Fatalf("expected oidc principal without trying later providers, got %+v (%d calls)", principal, third.calls)
+		}
+	})
+
+	t.Run("joins errors when all fail", func(t *testing.T) {
+		chain := NewChainProvider(&staticProvider{err: ErrInvalidToken}, &staticProvider{err: ErrTokenExpired})
+		_, err := chain.VerifyToken(context.Background(), "t")
+		if !errors.Is(err, ErrInvalidToken) || !errors.Is(err, ErrTokenExpired) {
+			t.Fatalf("expected both errors, got %v", err)
+		}
+	})
+
+	t.Run("empty chain rejects", func(t *testing.T) {
+		if _, err := NewChainProvider().VerifyToken(context.Background(), "t"); !errors.Is(err, ErrUnauthorized) {
+			t.Fatalf("expected ErrUnauthorized, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"saas-core-template/backend/internal/db"
+)
+
+const defaultSessionCacheTTL = 5 * time.Minute
+
+// SessionCache stores authenticated users keyed by a hash of the bearer token.
+type SessionCache interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+// WithSessionCache caches the token-to-user mapping so repeat requests with the
+// same token skip provider verification and the identity sync. Entries expire
+// with the token, or after maxTTL if that is sooner; maxTTL bounds how long a
+// token revoked at the provider keeps working.
+func WithSessionCache(cache SessionCache, maxTTL time.Duration) func(*Service) {
+	return func(s *Service) {
+		if cache == nil {
+			return
+		}
+		if maxTTL <= 0 {
+			maxTTL = defaultSessionCacheTTL
+		}
+		s.sessions = cache
+		s.sessionTTL = maxTTL
+	}
+}
+
+type cachedSession struct {
+	User User `json:"user"`
+	// Generation is the user's session generation when the entry was written;
+	// entries from an older generation are ignored.
+	Generation string `json:"generation,omitempty"`
+}
+
+// sessionCacheKey never stores the token itself, only its SHA-256.
+func sessionCacheKey(token string) string {
+	return db.HashSecret(token)
+}
+
+// sessionGenerationKey holds the user's current session generation. Bumping
+// it evicts every cached session of the user at once, since the cache has no
+// index from users to tokens.
+func sessionGenerationKey(userID string) string {
+	return "user:" + userID
+}
+
+// sessionTTL returns how long a session verified at now may be cached, or 0
+// when it must not be cached (no known expiry, or already expired).
+func sessionTTL(expiresAt time.Time, now time.Time, maxTTL time.Duration) time.Duration {
+	if expiresAt.IsZero() {
+		return 0
+	}
+	ttl := expiresAt.Sub(now)
+	if ttl <= 0 {
+		return 0
+	}
+	if ttl > maxTTL {
+		ttl = maxTTL
+	}
+	return ttl
+}
+
+// Cache failures are logged and otherwise ignored: Redis being unavailable
+// should only cost the per-request verification, not fail authentication.
+func (s *Service) cachedUser(ctx context.Context, token string) (User, bool) {
+	if s.sessions == nil {
+		return User{}, false
+	}
+
+	value, found, err := s.sessions.Get(ctx, sessionCacheKey(token))
+	if err != nil {
+		slog.Warn("auth session cache read failed", "error", err)
+		return User{}, false
+	}
+	if !found {
+		return User{}, false
+	}
+
+	var session cachedSession
+	if err := json.Unmarshal(value, &session); err != nil || session.User.ID == "" {
+		return User{}, false
+	}
+
+	generation, err := s.sessionGeneration(ctx, session.User.ID)
+	if err != nil {
+		slog.Warn("auth session cache read failed", "error", err)
+		return User{}, false
+	}
+	if session.Generation != generation {
+		return User{}, false
+	}
+	return session.User, true
+}
+
+func (s *Service) cacheUser(ctx context.Context, token string, principal VerifiedPrincipal, user User) {
+	if s.sessions == nil {
+		return
+	}
+
+	ttl := sessionTTL(principal.ExpiresAt, time.Now(), s.sessionTTL)
+	if ttl <= 0 {
+		return
+	}
+
+	generation, err := s.sessionGeneration(ctx, user.ID)
+	if err != nil {
+		slog.Warn("auth session cache read failed", "error", err)
+		return
+	}
+
+	value, err := json.Marshal(cachedSession{User: user, Generation: generation})
+	if err != nil {
+		return
+	}
+	if err := s.sessions.Set(ctx, sessionCacheKey(token), value, ttl); err != nil {
+		slog.Warn("auth session cache write failed", "error", err)
+	}
+}
+
+func (s *Service) sessionGeneration(ctx context.Context, userID string) (string, error) {
+	value, found, err := s.sessions.Get(ctx, sessionGenerationKey(userID))
+	if err != nil || !found {
+		return "", err
+	}
+	return string(value), nil
+}
+
+// evictSessions invalidates every cached session of the user by starting a new
+// generation. The generation only has to outlive entries written before it,
+// which expire within sessionTTL.
+func (s *Service) evictSessions(ctx context.Context, userID string) {
+	if s.sessions == nil {
+		return
+	}
+
+	generation := strconv.FormatInt(time.Now().UnixNano(), 36)
+	if err := s.sessions.Set(ctx, sessionGenerationKey(userID), []byte(generation), s.sessionTTL); err != nil {
+		slog.Warn("auth session cache eviction failed", "user_id", userID, "error", err)
+	}
+}
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+type memorySessionCache map[string][]byte
+
+func (c memorySessionCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	value, ok := c[key]
+	return value, ok, nil
+}
+
+func (c memorySessionCache) Set(_ context.Context, key string, value []byte, _ time.Duration) error {
+	c[key] = value
+	return nil
+}
+
+type failingProvider struct{ calls int }
+
+func (p *failingProvider) VerifyToken(context.Context, string) (VerifiedPrincipal, error) {
+	p.calls++
+	return VerifiedPrincipal{}, ErrInvalidToken
+}
+
+func TestSessionTTL(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		name      string
+		expiresAt time.Time
+		want      time.Duration
+	}{
+		{"unknown expiry is not cached", time.Time{}, 0},
+		{"expired is not cached", now.Add(-time.Second), 0},
+		{"expires with the token", now.Add(time.Minute), time.Minute},
+		{"capped at max", now.Add(time.Hour), 5 * time.Minute},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := sessionTTL(tc.expiresAt, now, 5*time.Minute); got != tc.want {
+				t.Fatalf("expected %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestAuthenticateUsesSessionCache(t *testing.T) {
+	cache := memorySessionCache{}
+	provider := &failingProvider{}
+	svc := NewService(provider, nil, WithSessionCache(cache, time.Minute))
+
+	cached, _ := json.Marshal(cachedSession{User: User{ID: "u1", PrimaryEmail: "a@example.com"}})
+	cache[sessionCacheKey("good-token")] = cached
+
+	user, err := svc.Authenticate(context.Background(), "good-token")
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	if user.ID != "u1" || provider.calls != 0 {
+		t.Fatalf("expected cached user without provider call, got %+v after %d calls", user, provider.calls)
+	}
+
+	if _, err := svc.Authenticate(context.Background(), "other-token"); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("expected provider error on miss, got %v", err)
+	}
+	if provider.calls != 1 {
+		t.Fatalf("expected provider called once on miss, got %d", provider.calls)
+	}
+}
+
+func TestEvictSessions(t *testing.T) {
+	ctx := context.Background()
+	cache := memorySessionCache{}
+	svc := NewService(&failingProvider{}, nil, WithSessionCache(cache, time.Minute))
+
+	user := User{ID: "u1", PrimaryEmail: "a@example.com"}
+	principal := VerifiedPrincipal{ExpiresAt: time.Now().Add(time.Hour)}
+	svc.cacheUser(ctx, "token-a", principal, user)
+	svc.cacheUser(ctx, "token-b", principal, user)
+	svc.cacheUser(ctx, "token-c", principal, User{ID: "u2"})
+
+	if _, ok := svc.cachedUser(ctx, "token-a"); !ok {
+		t.Fatalf("expected cached session before eviction")
+	}
+
+	svc.evictSessions(ctx, "u1")
+
+	for _, token := range []string{"token-a", "token-b"} {
+		if _, ok := svc.cachedUser(ctx, token); ok {
+			t.Fatalf("expected %s to be evicted", token)
+		}
+	}
+	if _, ok := svc.cachedUser(ctx, "token-c"); !ok {
+		t.Fatalf("expected another user's session to survive")
+	}
+
+	svc.cacheUser(ctx, "token-a", principal, user)
+	if got, ok := svc.cachedUser(ctx, "token-a"); !ok || got.ID != "u1" {
+		t.Fatalf("expected sessions cached after eviction to be served, got %+v", got)
+	}
+}
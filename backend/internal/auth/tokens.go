@@ -0,0 +1,224 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"saas-core-template/backend/internal/apikeys"
+	"saas-core-template/backend/internal/audit"
+	"saas-core-template/backend/internal/db"
+)
+
+const (
+	// PersonalAccessTokenPrefix marks tokens issued by this app so they are
+	// never sent to the identity provider.
+	PersonalAccessTokenPrefix = "pat_"
+
+	patSecretBytes   = 32
+	patDisplayLength = len(PersonalAccessTokenPrefix) + 8
+
+	// patUseResolution limits last_used_at writes and use audit events to one
+	// per token per minute.
+	patUseResolution = time.Minute
+)
+
+var (
+	ErrTokenNotFound = errors.New("personal access token not found")
+	ErrInvalidPAT    = errors.New("invalid personal access token")
+)
+
+type PersonalAccessToken struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"userId"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+}
+
+type CreateTokenInput struct {
+	UserID    string
+	Name      string
+	Scopes    []string
+	ExpiresAt *time.Time
+}
+
+// IsPersonalAccessToken reports whether token looks like a PAT.
+func IsPersonalAccessToken(token string) bool {
+	return strings.HasPrefix(token, PersonalAccessTokenPrefix)
+}
+
+// CreateToken issues a PAT for a user and returns it with its secret. The
+// secret is only available here.
+func (s *Service) CreateToken(ctx context.Context, input CreateTokenInput) (PersonalAccessToken, string, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return PersonalAccessToken{}, "", fmt.Errorf("missing name")
+	}
+
+	scopes, err := apikeys.ParseScopes(input.Scopes)
+	if err != nil {
+		return PersonalAccessToken{}, "", err
+	}
+
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(time.Now()) {
+		return PersonalAccessToken{}, "", fmt.Errorf("expiry must be in the future")
+	}
+
+	buf := make([]byte, patSecretBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return PersonalAccessToken{}, "", fmt.Errorf("generate token: %w", err)
+	}
+	secret := PersonalAccessTokenPrefix + hex.EncodeToString(buf)
+
+	token, err := scanToken(s.db.QueryRow(ctx, `
+		INSERT INTO personal_access_tokens (user_id, name, prefix, token_hash, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+tokenColumns,
+		input.UserID, name, secret[:patDisplayLength], db.HashSecret(secret), scopes, input.ExpiresAt,
+	))
+	if err != nil {
+		return PersonalAccessToken{}, "", fmt.Errorf("insert personal access token: %w", err)
+	}
+
+	_ = s.audit.Record(ctx, audit.Event{
+		UserID: input.UserID,
+		Action: "personal_access_token_created",
+		Data:   map[string]any{"token_id": token.ID, "name": token.Name, "prefix": token.Prefix, "scopes": token.Scopes},
+	})
+
+	return token, secret, nil
+}
+
+func (s *Service) ListTokens(ctx context.Context, userID string) ([]PersonalAccessToken, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT `+tokenColumns+`
+		FROM personal_access_tokens
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list personal access tokens: %w", err)
+	}
+	defer rows.Close()
+
+	out := []PersonalAccessToken{}
+	for rows.Next() {
+		token, err := scanToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan personal access token: %w", err)
+		}
+		out = append(out, token)
+	}
+	if rows.Err() != nil {
+		return nil, fmt.Errorf("list personal access tokens rows: %w", rows.Err())
+	}
+	return out, nil
+}
+
+// RevokeToken disables one of the user's tokens. Revoking twice is a no-op.
+func (s *Service) RevokeToken(ctx context.Context, userID string, tokenID string) error {
+	tokenID, ok := db.ParseUUID(tokenID)
+	if !ok {
+		return ErrTokenNotFound
+	}
+
+	var alreadyRevoked bool
+	err := s.db.QueryRow(ctx, `
+		WITH target AS (
+			SELECT id, revoked_at IS NOT NULL AS already_revoked
+			FROM personal_access_tokens
+			WHERE user_id = $1 AND id = $2::uuid
+		)
+		UPDATE personal_access_tokens t
+		SET revoked_at = COALESCE(t.revoked_at, now())
+		FROM target
+		WHERE t.id = target.id
+		RETURNING target.already_revoked
+	`, userID, tokenID).Scan(&alreadyRevoked)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrTokenNotFound
+		}
+		return fmt.Errorf("revoke personal access token: %w", err)
+	}
+
+	if !alreadyRevoked {
+		_ = s.audit.Record(ctx, audit.Event{
+			UserID: userID,
+			Action: "personal_access_token_revoked",
+			Data:   map[string]any{"token_id": tokenID},
+		})
+	}
+	return nil
+}
+
+// AuthenticateToken resolves a PAT to its user. Unknown, revoked and expired
+// tokens all return ErrInvalidPAT.
+func (s *Service) AuthenticateToken(ctx context.Context, secret string) (User, PersonalAccessToken, error) {
+	if !IsPersonalAccessToken(secret) {
+		return User{}, PersonalAccessToken{}, ErrInvalidPAT
+	}
+
+	var user User
+	token, err := scanToken(s.db.QueryRow(ctx, `
+		SELECT `+qualifiedTokenColumns+`, COALESCE(u.primary_email, '')
+		FROM personal_access_tokens t
+		INNER JOIN users u ON u.id = t.user_id
+		WHERE t.token_hash = $1
+		  AND t.revoked_at IS NULL
+		  AND (t.expires_at IS NULL OR t.expires_at > now())
+	`, db.HashSecret(secret)), &user.PrimaryEmail)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return User{}, PersonalAccessToken{}, ErrInvalidPAT
+		}
+		return User{}, PersonalAccessToken{}, fmt.Errorf("load personal access token: %w", err)
+	}
+	user.ID = token.UserID
+
+	s.recordTokenUse(ctx, token)
+
+	return user, token, nil
+}
+
+// recordTokenUse bumps last_used_at and writes a use audit event, at most once
+// per patUseResolution so busy CLI sessions do not flood either table.
+func (s *Service) recordTokenUse(ctx context.Context, token PersonalAccessToken) {
+	ct, err := s.db.Exec(ctx, `
+		UPDATE personal_access_tokens
+		SET last_used_at = now()
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < now() - ($2::int * interval '1 second'))
+	`, token.ID, int(patUseResolution.Seconds()))
+	if err != nil || ct.RowsAffected() == 0 {
+		return
+	}
+
+	_ = s.audit.Record(ctx, audit.Event{
+		UserID: token.UserID,
+		Action: "personal_access_token_used",
+		Data:   map[string]any{"token_id": token.ID, "prefix": token.Prefix},
+	})
+}
+
+const tokenColumns = `id::text, user_id::text, name, prefix, scopes, created_at, last_used_at, expires_at, revoked_at`
+
+const qualifiedTokenColumns = `t.id::text, t.user_id::text, t.name, t.prefix, t.scopes, t.created_at, t.last_used_at, t.expires_at, t.revoked_at`
+
+func scanToken(row pgx.Row, extra ...any) (PersonalAccessToken, error) {
+	var token PersonalAccessToken
+	dest := []any{&token.ID, &token.UserID, &token.Name, &token.Prefix, &token.Scopes, &token.CreatedAt, &token.LastUsedAt, &token.ExpiresAt, &token.RevokedAt}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return PersonalAccessToken{}, err
+	}
+	return token, nil
+}
@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestIsPersonalAccessToken(t *testing.T) {
+	tests := []struct {
+		token string
+		want  bool
+	}{
+		{token: "pat_0123456789abcdef", want: true},
+		{token: "pat_", want: true},
+		{token: "sk_0123456789abcdef", want: false},
+		{token: "dev:user@example.com", want: false},
+		{token: "eyJhbGciOiJSUzI1NiJ9.e30.sig", want: false},
+		{token: "PAT_0123456789abcdef", want: false},
+		{token: " pat_0123456789abcdef", want: false},
+		{token: "", want: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.token, func(t *testing.T) {
+			if got := IsPersonalAccessToken(tc.token); got != tc.want {
+				t.Fatalf("IsPersonalAccessToken(%q) = %v, want %v", tc.token, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAuthenticateRejectsPersonalAccessTokens(t *testing.T) {
+	provider := &staticProvider{principal: VerifiedPrincipal{Provider: "oidc", ProviderUserID: "u1"}}
+	svc := NewService(provider, nil)
+
+	if _, err := svc.Authenticate(context.Background(), "pat_0123456789abcdef"); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("expected a PAT to be rejected, got %v", err)
+	}
+	if provider.calls != 0 {
+		t.Fatalf("expected a PAT never to reach the provider, got %d calls", provider.calls)
+	}
+}
@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// tokenBucketScript refills the bucket from the elapsed time (using the Redis
+// clock, so workers with skewed clocks agree) and takes one token if it can.
+// It returns {allowed, wait_ms}.
+var tokenBucketScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local refill_ms = tonumber(ARGV[2])
+
+local now = redis.call('TIME')
+local now_ms = tonumber(now[1]) * 1000 + math.floor(tonumber(now[2]) / 1000)
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or capacity
+local ts = tonumber(state[2]) or now_ms
+
+tokens = math.min(capacity, tokens + math.max(0, now_ms - ts) / refill_ms)
+
+local allowed = 0
+local wait_ms = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+else
+  wait_ms = math.ceil((1 - tokens) * refill_ms)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now_ms)
+redis.call('PEXPIRE', KEYS[1], math.ceil(capacity * refill_ms) + 1000)
+
+return {allowed, wait_ms}
+`)
+
+// RateLimiter is a token bucket per key stored in Redis, shared by every
+// process that uses the same Redis and prefix.
+type RateLimiter struct {
+	client *redis.Client
+	prefix string
+}
+
+func NewRateLimiter(client *redis.Client, prefix string) *RateLimiter {
+	return &RateLimiter{client: client, prefix: prefix}
+}
+
+// Allow takes a token from key's bucket, which holds up to limit tokens and
+// refills limit tokens every per. When the bucket is empty it returns false
+// and how long until the next token is available.
+func (l *RateLimiter) Allow(ctx context.Context, key string, limit int, per time.Duration) (bool, time.Duration, error) {
+	if limit <= 0 || per <= 0 {
+		return true, 0, nil
+	}
+
+	refillMillis := math.Max(float64(per.Milliseconds())/float64(limit), 1)
+	result, err := tokenBucketScript.Run(ctx, l.client, []string{l.prefix + key}, limit, refillMillis).Int64Slice()
+	if err != nil {
+		return false, 0, fmt.Errorf("rate limit %q: %w", key, err)
+	}
+	if len(result) != 2 {
+		return false, 0, fmt.Errorf("rate limit %q: unexpected script result %v", key, result)
+	}
+
+	return result[0] == 1, time.Duration(result[1]) * time.Millisecond, nil
+}
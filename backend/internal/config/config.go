@@ -9,6 +9,7 @@ import (
 
 type Config struct {
 	Env         string
+	EnvSet      bool // APP_ENV was set explicitly rather than defaulted
 	Version     string
 	Port        string
 	DatabaseURL string
@@ -34,9 +35,25 @@ type Config struct {
 	JobsEnabled      bool
 	JobsWorkerID     string
 	JobsPollInterval time.Duration
+	JobsReapInterval time.Duration
+
+	JobsConcurrency       int
+	JobsTypeConcurrency   string
+	JobsQueues            string
+	JobsRateLimits        string
+	JobsRateLimitFailOpen bool
+	JobsShutdownTimeout   time.Duration
+	JobsListenEnabled     bool
+	JobsSchedulerEnabled  bool
+	JobsHTTPAddr          string
+
+	JobsDoneRetentionDays   int
+	JobsFailedRetentionDays int
+	JobsArchiveEnabled      bool
 
 	FileStorageProvider string
 	FileStorageDiskPath string
+	FileCleanupPending  bool
 	S3Bucket            string
 	S3Region            string
 	S3Endpoint          string
@@ -44,8 +61,21 @@ type Config struct {
 	S3SecretAccessKey   string
 	S3ForcePathStyle    bool
 
+	AuthProvider        string
+	AuthProviders       string
+	AuthSessionCacheTTL time.Duration
+	AuthDevSecret       string
+	AuthDevPlainTokens  bool
+	OIDCIssuer          string
+	OIDCAudience        string
+	OIDCJWKSURL         string
+	OIDCClockSkew       time.Duration
+	OIDCProviderName    string
+
 	ClerkSecretKey         string
 	ClerkAPIURL            string
+	ClerkIssuer            string
+	ClerkAuthorizedParties string
 	StripeSecretKey        string
 	StripeWebhookSecret    string
 	StripeAPIURL           string
@@ -57,6 +87,7 @@ type Config struct {
 func Load() (Config, error) {
 	cfg := Config{
 		Env:         getEnv("APP_ENV", "development"),
+		EnvSet:      os.Getenv("APP_ENV") != "",
 		Version:     getEnv("APP_VERSION", "dev"),
 		Port:        getEnv("PORT", "8080"),
 		DatabaseURL: os.Getenv("DATABASE_URL"),
@@ -82,9 +113,25 @@ func Load() (Config, error) {
 		JobsEnabled:      getEnvBool("JOBS_ENABLED", true),
 		JobsWorkerID:     getEnv("JOBS_WORKER_ID", "local"),
 		JobsPollInterval: getEnvDuration("JOBS_POLL_INTERVAL", 1*time.Second),
+		JobsReapInterval: getEnvDuration("JOBS_REAP_INTERVAL", 30*time.Second),
+
+		JobsConcurrency:       getEnvInt("JOBS_CONCURRENCY", 4),
+		JobsTypeConcurrency:   getEnv("JOBS_TYPE_CONCURRENCY", ""),
+		JobsQueues:            getEnv("JOBS_QUEUES", ""),
+		JobsRateLimits:        getEnv("JOBS_RATE_LIMITS", ""),
+		JobsRateLimitFailOpen: getEnvBool("JOBS_RATE_LIMIT_FAIL_OPEN", false),
+		JobsShutdownTimeout:   getEnvDuration("JOBS_SHUTDOWN_TIMEOUT", 25*time.Second),
+		JobsListenEnabled:     getEnvBool("JOBS_LISTEN_ENABLED", true),
+		JobsSchedulerEnabled:  getEnvBool("JOBS_SCHEDULER_ENABLED", true),
+		JobsHTTPAddr:          getEnv("JOBS_HTTP_ADDR", ""),
+
+		JobsDoneRetentionDays:   getEnvInt("JOBS_DONE_RETENTION_DAYS", 7),
+		JobsFailedRetentionDays: getEnvInt("JOBS_FAILED_RETENTION_DAYS", 30),
+		JobsArchiveEnabled:      getEnvBool("JOBS_ARCHIVE_ENABLED", false),
 
 		FileStorageProvider: getEnv("FILE_STORAGE_PROVIDER", "disk"),
 		FileStorageDiskPath: getEnv("FILE_STORAGE_DISK_PATH", "./.data/uploads"),
+		FileCleanupPending:  getEnvBool("FILE_CLEANUP_PENDING_ENABLED", false),
 		S3Bucket:            getEnv("S3_BUCKET", ""),
 		S3Region:            getEnv("S3_REGION", "auto"),
 		S3Endpoint:          getEnv("S3_ENDPOINT", ""),
@@ -92,8 +139,21 @@ func Load() (Config, error) {
 		S3SecretAccessKey:   getEnv("S3_SECRET_ACCESS_KEY", ""),
 		S3ForcePathStyle:    getEnvBool("S3_FORCE_PATH_STYLE", true),
 
+		AuthProvider:        getEnv("AUTH_PROVIDER", "clerk"),
+		AuthProviders:       getEnv("AUTH_PROVIDERS", ""),
+		AuthSessionCacheTTL: getEnvDuration("AUTH_SESSION_CACHE_TTL", 5*time.Minute),
+		AuthDevSecret:       os.Getenv("AUTH_DEV_SECRET"),
+		AuthDevPlainTokens:  getEnvBool("AUTH_DEV_PLAIN_TOKENS", false),
+		OIDCIssuer:          getEnv("OIDC_ISSUER", ""),
+		OIDCAudience:        getEnv("OIDC_AUDIENCE", ""),
+		OIDCJWKSURL:         getEnv("OIDC_JWKS_URL", ""),
+		OIDCClockSkew:       getEnvDuration("OIDC_CLOCK_SKEW", 60*time.Second),
+		OIDCProviderName:    getEnv("OIDC_PROVIDER_NAME", "oidc"),
+
 		ClerkSecretKey:         os.Getenv("CLERK_SECRET_KEY"),
 		ClerkAPIURL:            getEnv("CLERK_API_URL", ""),
+		ClerkIssuer:            getEnv("CLERK_ISSUER", ""),
+		ClerkAuthorizedParties: getEnv("CLERK_AUTHORIZED_PARTIES", ""),
 		StripeSecretKey:        os.Getenv("STRIPE_SECRET_KEY"),
 		StripeWebhookSecret:    os.Getenv("STRIPE_WEBHOOK_SECRET"),
 		StripeAPIURL:           getEnv("STRIPE_API_URL", ""),
@@ -106,10 +166,6 @@ func Load() (Config, error) {
 		return Config{}, fmt.Errorf("DATABASE_URL is required")
 	}
 
-	if cfg.RedisURL == "" {
-		return Config{}, fmt.Errorf("REDIS_URL is required")
-	}
-
 	return cfg, nil
 }
 
@@ -134,6 +190,18 @@ func getEnvBool(key string, fallback bool) bool {
 	return parsed
 }
 
+func getEnvInt(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return fallback
+	}
+	return parsed
+}
+
 func getEnvDuration(key string, fallback time.Duration) time.Duration {
 	value := os.Getenv(key)
 	if value == "" {
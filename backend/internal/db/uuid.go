@@ -0,0 +1,27 @@
+package db
+
+import "strings"
+
+// ParseUUID normalizes id and reports whether it is a UUID in its canonical
+// hyphenated form. Callers compare id = $1::uuid so lookups can use the primary
+// key, and treat malformed IDs as not found instead of failing the cast.
+func ParseUUID(id string) (string, bool) {
+	id = strings.ToLower(strings.TrimSpace(id))
+	if len(id) != 36 {
+		return "", false
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return "", false
+			}
+		default:
+			if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+				return "", false
+			}
+		}
+	}
+	return id, true
+}
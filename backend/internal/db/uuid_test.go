@@ -0,0 +1,29 @@
+package db
+
+import "testing"
+
+func TestParseUUID(t *testing.T) {
+	valid := map[string]string{
+		"7f1c2a4e-3b5d-4c6e-8f90-a1b2c3d4e5f6":    "7f1c2a4e-3b5d-4c6e-8f90-a1b2c3d4e5f6",
+		"  7F1C2A4E-3B5D-4C6E-8F90-A1B2C3D4E5F6 ": "7f1c2a4e-3b5d-4c6e-8f90-a1b2c3d4e5f6",
+	}
+	for in, want := range valid {
+		got, ok := ParseUUID(in)
+		if !ok || got != want {
+			t.Fatalf("ParseUUID(%q) = %q, %v; want %q", in, got, ok, want)
+		}
+	}
+
+	for _, in := range []string{
+		"",
+		"42",
+		"7f1c2a4e3b5d4c6e8f90a1b2c3d4e5f6",
+		"7f1c2a4e-3b5d-4c6e-8f90-a1b2c3d4e5fg",
+		"7f1c2a4e-3b5d-4c6e_8f90-a1b2c3d4e5f6",
+		"{7f1c2a4e-3b5d-4c6e-8f90-a1b2c3d4e5f6}",
+	} {
+		if _, ok := ParseUUID(in); ok {
+			t.Fatalf("expected %q to be rejected", in)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package db
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// EmptyToNil trims value and returns nil when nothing is left, so optional
+// text columns store NULL instead of an empty string.
+func EmptyToNil(value string) any {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+	return strings.TrimSpace(value)
+}
+
+// HashSecret returns the hex SHA-256 of a generated secret such as an API key
+// or personal access token. A plain hash is enough because those secrets are
+// 256 random bits; a slow password hash would only add latency to every request.
+func HashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
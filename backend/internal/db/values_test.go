@@ -0,0 +1,36 @@
+package db
+
+import "testing"
+
+func TestEmptyToNil(t *testing.T) {
+	if got := EmptyToNil("  "); got != nil {
+		t.Fatalf("expected blank input to be nil, got %v", got)
+	}
+	if got := EmptyToNil(" key "); got != "key" {
+		t.Fatalf("expected trimmed input, got %v", got)
+	}
+}
+
+func TestHashSecret(t *testing.T) {
+	tests := []struct {
+		secret string
+		want   string
+	}{
+		{secret: "", want: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+		{secret: "abc", want: "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.secret, func(t *testing.T) {
+			if got := HashSecret(tc.secret); got != tc.want {
+				t.Fatalf("HashSecret(%q) = %s, want %s", tc.secret, got, tc.want)
+			}
+		})
+	}
+
+	t.Run("distinct secrets hash differently", func(t *testing.T) {
+		if HashSecret("sk_a") == HashSecret("sk_b") {
+			t.Fatalf("expected distinct hashes")
+		}
+	})
+}
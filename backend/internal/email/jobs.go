@@ -0,0 +1,51 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"saas-core-template/backend/internal/jobs"
+)
+
+const SendJobType = "send_email"
+
+// SendJob is the payload of a send_email job.
+type SendJob struct {
+	Kind    string `json:"kind,omitempty"`
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Text    string `json:"text,omitempty"`
+	HTML    string `json:"html,omitempty"`
+}
+
+// RegisterJobs registers the email job handlers, sending from the given address.
+func RegisterJobs(registry *jobs.Registry, sender Sender, from string) {
+	jobs.Register(registry, SendJobType, func(ctx context.Context, job SendJob) error {
+		msg := Message{
+			To:      job.To,
+			From:    from,
+			Subject: job.Subject,
+			Text:    job.Text,
+			HTML:    job.HTML,
+		}
+		if err := ValidateMessage(msg); err != nil {
+			return jobs.Permanent(err)
+		}
+
+		err := sender.Send(ctx, msg)
+		var resendErr *ResendError
+		if errors.As(err, &resendErr) && resendErr.Permanent() {
+			return jobs.Permanent(err)
+		}
+		return err
+	},
+		jobs.WithTimeout(30*time.Second),
+		jobs.WithRetryPolicy(jobs.RetryPolicy{
+			Strategy:  jobs.BackoffExponential,
+			BaseDelay: 10 * time.Second,
+			MaxDelay:  30 * time.Minute,
+			Jitter:    0.2,
+		}),
+	)
+}
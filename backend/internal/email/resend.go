@@ -11,6 +11,25 @@ import (
 	"time"
 )
 
+// ResendError is a non-2xx response from the Resend API.
+type ResendError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *ResendError) Error() string {
+	return fmt.Sprintf("resend status %d: %s", e.StatusCode, e.Body)
+}
+
+// Permanent reports whether resending the same message cannot succeed: any 4xx
+// except timeouts and rate limiting.
+func (e *ResendError) Permanent() bool {
+	if e.StatusCode == http.StatusRequestTimeout || e.StatusCode == http.StatusTooManyRequests {
+		return false
+	}
+	return e.StatusCode >= 400 && e.StatusCode < 500
+}
+
 type ResendSender struct {
 	apiKey string
 	client *http.Client
@@ -59,7 +78,7 @@ func (s *ResendSender) Send(ctx context.Context, msg Message) error {
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		b, _ := io.ReadAll(io.LimitReader(resp.Body, 2048))
-		return fmt.Errorf("resend status %d: %s", resp.StatusCode, strings.TrimSpace(string(b)))
+		return &ResendError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(b))}
 	}
 
 	return nil
@@ -0,0 +1,107 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"saas-core-template/backend/internal/jobs"
+)
+
+const CleanupPendingJobType = "files_cleanup_pending"
+
+// CleanupPendingJob is the payload of a files_cleanup_pending job.
+type CleanupPendingJob struct {
+	// OlderThanHours defaults to 24.
+	OlderThanHours int `json:"olderThanHours,omitempty"`
+}
+
+// RegisterJobs registers the file maintenance job handlers.
+func RegisterJobs(registry *jobs.Registry, service *Service) {
+	jobs.Register(registry, CleanupPendingJobType, func(ctx context.Context, job CleanupPendingJob) error {
+		olderThan := time.Duration(job.OlderThanHours) * time.Hour
+		if olderThan <= 0 {
+			olderThan = 24 * time.Hour
+		}
+
+		deleted, err := service.DeleteStalePending(ctx, olderThan)
+		if err != nil {
+			return err
+		}
+		if deleted > 0 {
+			slog.Info("deleted stale pending uploads", "count", deleted)
+		}
+		return nil
+	}, jobs.WithTimeout(5*time.Minute), jobs.WithConcurrency(1))
+}
+
+// DeleteStalePending removes uploads that were started but never completed,
+// along with any bytes that reached storage. Rows are deleted first, and only
+// while still pending, so an upload completed concurrently keeps its object.
+// A failed object delete leaves orphaned bytes behind and is only logged.
+func (s *Service) DeleteStalePending(ctx context.Context, olderThan time.Duration) (int, error) {
+	rows, err := s.db.Query(ctx, `
+		DELETE FROM file_objects
+		WHERE id IN (
+			SELECT id
+			FROM file_objects
+			WHERE status = 'pending'
+			  AND created_at < $1
+			ORDER BY created_at ASC
+			LIMIT 500
+		)
+		  AND status = 'pending'
+		RETURNING id::text, provider, storage_key
+	`, time.Now().UTC().Add(-olderThan))
+	if err != nil {
+		return 0, fmt.Errorf("delete stale pending files: %w", err)
+	}
+
+	var stale []fileRecord
+	for rows.Next() {
+		var rec fileRecord
+		if err := rows.Scan(&rec.ID, &rec.Provider, &rec.StorageKey); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("scan stale pending file: %w", err)
+		}
+		rec.Provider = strings.ToLower(strings.TrimSpace(rec.Provider))
+		stale = append(stale, rec)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("delete stale pending files rows: %w", err)
+	}
+
+	for _, rec := range stale {
+		if err := s.deleteStoredObject(ctx, rec); err != nil {
+			slog.Warn("failed to delete stale upload object", "file_id", rec.ID, "storage_key", rec.StorageKey, "error", err)
+		}
+	}
+
+	return len(stale), nil
+}
+
+func (s *Service) deleteStoredObject(ctx context.Context, rec fileRecord) error {
+	switch rec.Provider {
+	case "disk":
+		target := filepath.Join(s.diskPath, filepath.FromSlash(rec.StorageKey))
+		for _, path := range []string{target, target + ".tmp"} {
+			if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("remove %s: %w", path, err)
+			}
+		}
+		return nil
+	case "s3":
+		if s.s3 == nil {
+			return fmt.Errorf("s3 not configured")
+		}
+		return s.s3.Delete(ctx, rec.StorageKey)
+	default:
+		return fmt.Errorf("unknown provider %q", rec.Provider)
+	}
+}
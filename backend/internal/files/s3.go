@@ -106,3 +106,17 @@ func (p *S3Provider) PresignGet(ctx context.Context, key string, ttl time.Durati
 
 	return out.URL, nil
 }
+
+func (p *S3Provider) Delete(ctx context.Context, key string) error {
+	if strings.TrimSpace(key) == "" {
+		return fmt.Errorf("missing key")
+	}
+
+	if _, err := p.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(p.bucket),
+		Key:    aws.String(key),
+	}); err != nil {
+		return fmt.Errorf("delete object: %w", err)
+	}
+	return nil
+}
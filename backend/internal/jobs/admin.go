@@ -0,0 +1,374 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"saas-core-template/backend/internal/db"
+)
+
+const (
+	StatusQueued     = "queued"
+	StatusProcessing = "processing"
+	StatusDone       = "done"
+	StatusFailed     = "failed"
+	StatusCancelled  = "cancelled"
+)
+
+var (
+	ErrNotFound      = errors.New("job not found")
+	ErrEmptySelector = errors.New("select jobs by id, type, or all")
+)
+
+// ValidStatus reports whether status is one of the known job statuses.
+func ValidStatus(status string) bool {
+	switch status {
+	case StatusQueued, StatusProcessing, StatusDone, StatusFailed, StatusCancelled:
+		return true
+	default:
+		return false
+	}
+}
+
+// JobRecord is a full view of a job row for inspection and admin tooling.
+type JobRecord struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	Status      string          `json:"status"`
+	Queue       string          `json:"queue"`
+	Priority    int             `json:"priority"`
+	Payload     json.RawMessage `json:"payload"`
+	Metadata    json.RawMessage `json:"metadata"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"maxAttempts"`
+	RunAt       time.Time       `json:"runAt"`
+	LockedBy    string          `json:"lockedBy,omitempty"`
+	LockedUntil *time.Time      `json:"lockedUntil,omitempty"`
+	LastError   string          `json:"lastError,omitempty"`
+	UniqueKey   string          `json:"uniqueKey,omitempty"`
+	BatchID     string          `json:"batchId,omitempty"`
+	CreatedAt   time.Time       `json:"createdAt"`
+	UpdatedAt   time.Time       `json:"updatedAt"`
+}
+
+const jobRecordColumns = `
+	id::text, type, status, queue, priority, payload::text, metadata::text, attempts, max_attempts, run_at,
+	COALESCE(locked_by, ''), locked_until, COALESCE(last_error, ''), COALESCE(unique_key, ''), COALESCE(batch_id::text, ''),
+	created_at, updated_at
+`
+
+func scanJobRecord(row pgx.Row) (JobRecord, error) {
+	var rec JobRecord
+	var payload, metadata string
+	if err := row.Scan(
+		&rec.ID,
+		&rec.Type,
+		&rec.Status,
+		&rec.Queue,
+		&rec.Priority,
+		&payload,
+		&metadata,
+		&rec.Attempts,
+		&rec.MaxAttempts,
+		&rec.RunAt,
+		&rec.LockedBy,
+		&rec.LockedUntil,
+		&rec.LastError,
+		&rec.UniqueKey,
+		&rec.BatchID,
+		&rec.CreatedAt,
+		&rec.UpdatedAt,
+	); err != nil {
+		return JobRecord{}, err
+	}
+	rec.Payload = json.RawMessage(payload)
+	rec.Metadata = json.RawMessage(metadata)
+	return rec, nil
+}
+
+type ListFilter struct {
+	Status  string
+	Type    string
+	Queue   string
+	BatchID string
+	// Limit defaults to 50 and is capped at 1000.
+	Limit int
+}
+
+// List returns jobs matching filter, most recently updated first.
+func (s *Store) List(ctx context.Context, filter ListFilter) ([]JobRecord, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > 1000 {
+		limit = 1000
+	}
+	batchID := strings.TrimSpace(filter.BatchID)
+	if batchID != "" {
+		id, ok := db.ParseUUID(batchID)
+		if !ok {
+			return []JobRecord{}, nil
+		}
+		batchID = id
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT `+jobRecordColumns+`
+		FROM jobs
+		WHERE ($1 = '' OR status = $1)
+		  AND ($2 = '' OR type = $2)
+		  AND ($4 = '' OR queue = $4)
+		  AND ($5 = '' OR batch_id = NULLIF($5, '')::uuid)
+		ORDER BY updated_at DESC
+		LIMIT $3
+	`, strings.TrimSpace(filter.Status), strings.TrimSpace(filter.Type), limit, strings.TrimSpace(filter.Queue), batchID)
+	if err != nil {
+		return nil, fmt.Errorf("list jobs: %w", err)
+	}
+	defer rows.Close()
+
+	out := []JobRecord{}
+	for rows.Next() {
+		rec, err := scanJobRecord(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan job: %w", err)
+		}
+		out = append(out, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list jobs rows: %w", err)
+	}
+	return out, nil
+}
+
+// Get returns a single job or ErrNotFound.
+func (s *Store) Get(ctx context.Context, jobID string) (JobRecord, error) {
+	id, ok := db.ParseUUID(jobID)
+	if !ok {
+		return JobRecord{}, ErrNotFound
+	}
+	rec, err := scanJobRecord(s.db.QueryRow(ctx, `
+		SELECT `+jobRecordColumns+`
+		FROM jobs
+		WHERE id = $1::uuid
+	`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return JobRecord{}, ErrNotFound
+	}
+	if err != nil {
+		return JobRecord{}, fmt.Errorf("get job: %w", err)
+	}
+	return rec, nil
+}
+
+// Selector picks the jobs a bulk operation applies to. At least one field must be
+// set; All matches every job in the operation's source status.
+type Selector struct {
+	IDs  []string
+	Type string
+	All  bool
+}
+
+func (sel Selector) empty() bool {
+	return len(nonNilStrings(sel.IDs)) == 0 && strings.TrimSpace(sel.Type) == "" && !sel.All
+}
+
+// RetryResult reports the outcome of RetryFailed.
+type RetryResult struct {
+	Retried int64
+	// Skipped counts matching failed jobs left failed because another job with
+	// the same unique key is already queued or processing (or is being retried
+	// in the same call).
+	Skipped int64
+}
+
+// RetryFailed moves failed jobs back to the queue with a fresh attempt budget.
+// jobs.attempts restarts at zero, but attempt numbers in job_attempts keep
+// counting up, so a retried job's history is not renumbered.
+func (s *Store) RetryFailed(ctx context.Context, sel Selector) (RetryResult, error) {
+	if sel.empty() {
+		return RetryResult{}, ErrEmptySelector
+	}
+	ids, ok := selectorIDs(sel.IDs)
+	if !ok {
+		return RetryResult{}, nil
+	}
+
+	// A retried job must not collide with uq_jobs_active_unique_key, so keyed
+	// jobs are skipped while an active job holds the key, and only the most
+	// recent failed job per key is requeued. Retried members of an unfinished
+	// batch count as pending again. A batch that already finished (and ran its
+	// callback) is left as is.
+	var res RetryResult
+	var matched int64
+	err := s.db.QueryRow(ctx, `
+		WITH matched AS (
+			SELECT id, unique_key, updated_at
+			FROM jobs
+			WHERE status = 'failed'
+			  AND (cardinality($1::uuid[]) = 0 OR id = ANY($1::uuid[]))
+			  AND ($2 = '' OR type = $2)
+		), candidates AS (
+			SELECT DISTINCT ON (COALESCE(m.unique_key, m.id::text)) m.id
+			FROM matched m
+			WHERE m.unique_key IS NULL
+			   OR NOT EXISTS (
+				SELECT 1 FROM jobs a
+				WHERE a.unique_key = m.unique_key
+				  AND a.status IN ('queued', 'processing')
+			   )
+			ORDER BY COALESCE(m.unique_key, m.id::text), m.updated_at DESC
+		), retried AS (
+			UPDATE jobs
+			SET status = 'queued',
+			    attempts = 0,
+			    run_at = now(),
+			    locked_until = NULL,
+			    locked_by = NULL,
+			    updated_at = now()
+			FROM candidates c
+			WHERE jobs.id = c.id
+			  AND jobs.status = 'failed'
+			RETURNING jobs.batch_id
+		), batch_counts AS (
+			SELECT batch_id, count(*)::int AS n
+			FROM retried
+			WHERE batch_id IS NOT NULL
+			GROUP BY batch_id
+		), reopened AS (
+			UPDATE job_batches b
+			SET failed = b.failed - c.n,
+			    pending = b.pending + c.n,
+			    updated_at = now()
+			FROM batch_counts c
+			WHERE b.id = c.batch_id
+			  AND b.finished_at IS NULL
+		)
+		SELECT (SELECT count(*) FROM retried), (SELECT count(*) FROM matched)
+	`, ids, strings.TrimSpace(sel.Type)).Scan(&res.Retried, &matched)
+	if err != nil {
+		return RetryResult{}, fmt.Errorf("retry failed jobs: %w", err)
+	}
+	res.Skipped = matched - res.Retried
+
+	if res.Retried > 0 {
+		if err := notifyListeners(ctx, s.db, "retry", ""); err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
+
+// CancelQueued marks queued jobs as cancelled so no worker will claim them.
+// Jobs that are already processing are not interrupted.
+func (s *Store) CancelQueued(ctx context.Context, sel Selector) (int64, error) {
+	if sel.empty() {
+		return 0, ErrEmptySelector
+	}
+	ids, ok := selectorIDs(sel.IDs)
+	if !ok {
+		return 0, nil
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("begin cancel tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+		UPDATE jobs
+		SET status = 'cancelled',
+		    updated_at = now()
+		WHERE status = 'queued'
+		  AND (cardinality($1::uuid[]) = 0 OR id = ANY($1::uuid[]))
+		  AND ($2 = '' OR type = $2)
+		RETURNING id::text, batch_id IS NOT NULL
+	`, ids, strings.TrimSpace(sel.Type))
+	if err != nil {
+		return 0, fmt.Errorf("cancel queued jobs: %w", err)
+	}
+
+	var cancelled int64
+	var batched []string
+	for rows.Next() {
+		var id string
+		var inBatch bool
+		if err := rows.Scan(&id, &inBatch); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("scan cancelled job: %w", err)
+		}
+		cancelled++
+		if inBatch {
+			batched = append(batched, id)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("cancel queued jobs rows: %w", err)
+	}
+
+	for _, id := range batched {
+		if err := recordBatchOutcome(ctx, tx, id, StatusCancelled); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("commit cancel tx: %w", err)
+	}
+	return cancelled, nil
+}
+
+// Purge deletes jobs in a terminal status (done, failed or cancelled) last updated
+// more than olderThan ago.
+func (s *Store) Purge(ctx context.Context, status string, olderThan time.Duration) (int64, error) {
+	switch status {
+	case StatusDone, StatusFailed, StatusCancelled:
+	default:
+		return 0, fmt.Errorf("cannot purge jobs with status %q", status)
+	}
+	if olderThan < 0 {
+		return 0, fmt.Errorf("olderThan must not be negative")
+	}
+
+	ct, err := s.db.Exec(ctx, `
+		DELETE FROM jobs
+		WHERE status = $1
+		  AND updated_at < $2
+	`, status, time.Now().UTC().Add(-olderThan))
+	if err != nil {
+		return 0, fmt.Errorf("purge jobs: %w", err)
+	}
+	return ct.RowsAffected(), nil
+}
+
+// selectorIDs returns the valid job IDs among values in canonical form. It
+// reports false when IDs were given but none is valid, so the selector matches
+// nothing rather than falling back to the other fields.
+func selectorIDs(values []string) ([]string, bool) {
+	given := nonNilStrings(values)
+	ids := make([]string, 0, len(given))
+	for _, v := range given {
+		if id, ok := db.ParseUUID(v); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids, len(given) == 0 || len(ids) > 0
+}
+
+func nonNilStrings(values []string) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			out = append(out, strings.TrimSpace(v))
+		}
+	}
+	return out
+}
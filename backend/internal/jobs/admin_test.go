@@ -0,0 +1,167 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"saas-core-template/backend/internal/testdb"
+)
+
+func TestAdminRetryCancelPurge(t *testing.T) {
+	ctx := context.Background()
+	pool := testdb.New(t)
+	store := NewStore(pool)
+
+	enqueue := func(jobType string) string {
+		t.Helper()
+		id, err := store.Enqueue(ctx, jobType, map[string]string{}, time.Now().UTC())
+		if err != nil {
+			t.Fatalf("enqueue: %v", err)
+		}
+		return id
+	}
+	fail := func(id string) {
+		t.Helper()
+		if _, err := pool.Exec(ctx, `UPDATE jobs SET status = 'failed', attempts = 3 WHERE id = $1::uuid`, id); err != nil {
+			t.Fatalf("fail job: %v", err)
+		}
+	}
+	expectStatus := func(id string, want string) JobRecord {
+		t.Helper()
+		rec, err := store.Get(ctx, id)
+		if err != nil {
+			t.Fatalf("get %s: %v", id, err)
+		}
+		if rec.Status != want {
+			t.Fatalf("expected job %s to be %s, got %s", id, want, rec.Status)
+		}
+		return rec
+	}
+
+	exportA, exportB, email := enqueue("export"), enqueue("export"), enqueue("send_email")
+	fail(exportA)
+	fail(exportB)
+
+	if _, err := store.RetryFailed(ctx, Selector{}); !errors.Is(err, ErrEmptySelector) {
+		t.Fatalf("expected ErrEmptySelector, got %v", err)
+	}
+
+	if res, err := store.RetryFailed(ctx, Selector{IDs: []string{exportA, email}}); err != nil || res.Retried != 1 {
+		t.Fatalf("expected one job retried by ID, got %+v, %v", res, err)
+	}
+	if rec := expectStatus(exportA, StatusQueued); rec.Attempts != 0 {
+		t.Fatalf("expected a retried job to get a fresh attempt budget, got %d attempts", rec.Attempts)
+	}
+	expectStatus(exportB, StatusFailed)
+
+	if res, err := store.RetryFailed(ctx, Selector{Type: "export"}); err != nil || res.Retried != 1 {
+		t.Fatalf("expected one job retried by type, got %+v, %v", res, err)
+	}
+	expectStatus(exportB, StatusQueued)
+
+	if n, err := store.CancelQueued(ctx, Selector{Type: "send_email"}); err != nil || n != 1 {
+		t.Fatalf("expected one job cancelled, got %d, %v", n, err)
+	}
+	expectStatus(email, StatusCancelled)
+	expectStatus(exportA, StatusQueued)
+
+	if _, err := store.Purge(ctx, StatusQueued, time.Hour); err == nil {
+		t.Fatalf("expected purging queued jobs to be refused")
+	}
+	if n, err := store.Purge(ctx, StatusCancelled, time.Hour); err != nil || n != 0 {
+		t.Fatalf("expected a recent job to be kept, got %d, %v", n, err)
+	}
+	if _, err := pool.Exec(ctx, `UPDATE jobs SET updated_at = now() - interval '2 hours' WHERE id = $1::uuid`, email); err != nil {
+		t.Fatalf("age job: %v", err)
+	}
+	if n, err := store.Purge(ctx, StatusCancelled, time.Hour); err != nil || n != 1 {
+		t.Fatalf("expected one job purged, got %d, %v", n, err)
+	}
+	if _, err := store.Get(ctx, email); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected the purged job to be gone, got %v", err)
+	}
+}
+
+func TestAdminRetrySkipsActiveUniqueKey(t *testing.T) {
+	ctx := context.Background()
+	pool := testdb.New(t)
+	store := NewStore(pool)
+
+	enqueue := func(key string) string {
+		t.Helper()
+		id, err := store.Enqueue(ctx, "welcome_email", map[string]string{}, time.Now().UTC(), WithUniqueKey(key, OnConflictSkip))
+		if err != nil {
+			t.Fatalf("enqueue: %v", err)
+		}
+		return id
+	}
+	fail := func(id string) {
+		t.Helper()
+		if _, err := pool.Exec(ctx, `UPDATE jobs SET status = 'failed', attempts = 3 WHERE id = $1::uuid`, id); err != nil {
+			t.Fatalf("fail job: %v", err)
+		}
+	}
+
+	stale := enqueue("welcome_email:1")
+	fail(stale)
+	active := enqueue("welcome_email:1")
+	other := enqueue("welcome_email:2")
+	fail(other)
+
+	res, err := store.RetryFailed(ctx, Selector{All: true})
+	if err != nil {
+		t.Fatalf("expected retry to succeed despite the active job, got %v", err)
+	}
+	if res.Retried != 1 || res.Skipped != 1 {
+		t.Fatalf("expected one job retried and one skipped, got %+v", res)
+	}
+
+	for id, want := range map[string]string{stale: StatusFailed, active: StatusQueued, other: StatusQueued} {
+		rec, err := store.Get(ctx, id)
+		if err != nil {
+			t.Fatalf("get %s: %v", id, err)
+		}
+		if rec.Status != want {
+			t.Fatalf("expected job %s to be %s, got %s", id, want, rec.Status)
+		}
+	}
+}
+
+func TestAdminRetryKeepsAttemptNumbers(t *testing.T) {
+	ctx := context.Background()
+	pool := testdb.New(t)
+	store := NewStore(pool)
+	claimer := NewClaimer(pool, ClaimerConfig{WorkerID: "test"})
+
+	id, err := store.Enqueue(ctx, "export", map[string]string{}, time.Now().UTC())
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+
+	runAndFail := func() {
+		t.Helper()
+		job, err := claimer.Claim(ctx, ClaimFilter{})
+		if err != nil || job == nil || job.ID != id {
+			t.Fatalf("expected to claim %s, got %+v, %v", id, job, err)
+		}
+		if err := claimer.Fail(ctx, FailureInput{JobID: job.ID, Attempts: job.Attempts, MaxAttempts: job.Attempts, Err: errors.New("boom")}); err != nil {
+			t.Fatalf("fail: %v", err)
+		}
+	}
+
+	runAndFail()
+	if res, err := store.RetryFailed(ctx, Selector{IDs: []string{id}}); err != nil || res.Retried != 1 {
+		t.Fatalf("expected the job to be retried, got %+v, %v", res, err)
+	}
+	runAndFail()
+
+	attempts, err := store.ListAttempts(ctx, id)
+	if err != nil {
+		t.Fatalf("list attempts: %v", err)
+	}
+	if len(attempts) != 2 || attempts[0].Attempt != 1 || attempts[1].Attempt != 2 {
+		t.Fatalf("expected attempts numbered 1 and 2 across the retry, got %+v", attempts)
+	}
+}
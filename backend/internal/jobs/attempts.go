@@ -0,0 +1,107 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"saas-core-template/backend/internal/db"
+)
+
+const (
+	AttemptRunning     = "running"
+	AttemptSucceeded   = "succeeded"
+	AttemptFailed      = "failed"
+	AttemptLockExpired = "lock_expired"
+)
+
+// maxAttemptErrorLen bounds the error stored per attempt; last_error on the job is shorter.
+const maxAttemptErrorLen = 8000
+
+// Attempt is one execution of a job by a worker.
+type Attempt struct {
+	ID         string     `json:"id"`
+	JobID      string     `json:"jobId"`
+	Attempt    int        `json:"attempt"`
+	WorkerID   string     `json:"workerId"`
+	StartedAt  time.Time  `json:"startedAt"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+	DurationMS *int64     `json:"durationMs,omitempty"`
+	Outcome    string     `json:"outcome"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// ListAttempts returns a job's attempts in the order they started. It returns
+// ErrNotFound for a malformed job ID.
+func (s *Store) ListAttempts(ctx context.Context, jobID string) ([]Attempt, error) {
+	id, ok := db.ParseUUID(jobID)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	rows, err := s.db.Query(ctx, `
+		SELECT id::text, job_id::text, attempt, worker_id, started_at, finished_at, duration_ms, outcome, COALESCE(error, '')
+		FROM job_attempts
+		WHERE job_id = $1::uuid
+		ORDER BY started_at ASC
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("list job attempts: %w", err)
+	}
+	defer rows.Close()
+
+	out := []Attempt{}
+	for rows.Next() {
+		var a Attempt
+		if err := rows.Scan(&a.ID, &a.JobID, &a.Attempt, &a.WorkerID, &a.StartedAt, &a.FinishedAt, &a.DurationMS, &a.Outcome, &a.Error); err != nil {
+			return nil, fmt.Errorf("scan job attempt: %w", err)
+		}
+		out = append(out, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list job attempts rows: %w", err)
+	}
+	return out, nil
+}
+
+// startAttempt opens the job's next attempt. Attempts are numbered from the
+// job's history rather than jobs.attempts, which an admin retry resets.
+func startAttempt(ctx context.Context, db DBTX, jobID string, workerID string) error {
+	// Close any attempt left open by a worker that vanished without being reclaimed.
+	if err := finishAttempt(ctx, db, jobID, AttemptLockExpired, ErrLockExpired); err != nil {
+		return err
+	}
+
+	if _, err := db.Exec(ctx, `
+		INSERT INTO job_attempts (job_id, attempt, worker_id)
+		SELECT $1, COALESCE(max(attempt), 0) + 1, $2
+		FROM job_attempts
+		WHERE job_id = $1
+	`, jobID, workerID); err != nil {
+		return fmt.Errorf("insert job attempt: %w", err)
+	}
+	return nil
+}
+
+// finishAttempt closes the job's open attempt, if any.
+func finishAttempt(ctx context.Context, db DBTX, jobID string, outcome string, attemptErr error) error {
+	var errText any
+	if attemptErr != nil {
+		text := attemptErr.Error()
+		if len(text) > maxAttemptErrorLen {
+			text = text[:maxAttemptErrorLen]
+		}
+		errText = text
+	}
+
+	if _, err := db.Exec(ctx, `
+		UPDATE job_attempts
+		SET finished_at = now(),
+		    duration_ms = (EXTRACT(EPOCH FROM (now() - started_at)) * 1000)::bigint,
+		    outcome = $2,
+		    error = $3
+		WHERE job_id = $1 AND finished_at IS NULL
+	`, jobID, outcome, errText); err != nil {
+		return fmt.Errorf("finish job attempt: %w", err)
+	}
+	return nil
+}
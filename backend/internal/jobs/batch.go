@@ -0,0 +1,276 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"saas-core-template/backend/internal/db"
+)
+
+var (
+	ErrBatchNotFound = errors.New("job batch not found")
+	// ErrBatchUniqueKey is returned when a batch member uses WithUniqueKey. A
+	// deduplicated member would belong to another batch (or none), so the
+	// batch could never finish.
+	ErrBatchUniqueKey = errors.New("batch jobs cannot use unique keys")
+)
+
+// Batch groups jobs so their progress can be tracked together and a callback
+// job runs once every member has finished. Build it with NewBatch and Add,
+// then pass it to Store.EnqueueBatch.
+type Batch struct {
+	description string
+	members     []batchMember
+	callback    *batchMember
+}
+
+type batchMember struct {
+	jobType string
+	payload any
+	runAt   time.Time
+	opts    []func(*EnqueueOptions)
+}
+
+func NewBatch(description string) *Batch {
+	return &Batch{description: strings.TrimSpace(description)}
+}
+
+// Add appends a member job. It returns the batch for chaining.
+func (b *Batch) Add(jobType string, payload any, runAt time.Time, opts ...func(*EnqueueOptions)) *Batch {
+	b.members = append(b.members, batchMember{jobType: jobType, payload: payload, runAt: runAt, opts: opts})
+	return b
+}
+
+// OnComplete sets the job enqueued once every member is done, failed or
+// cancelled. When payload encodes to a JSON object (or is nil), its "batchId"
+// field is set to the batch ID so the handler can load the outcome with
+// Store.GetBatch; embed BatchCallback in the payload type to receive it.
+func (b *Batch) OnComplete(jobType string, payload any, opts ...func(*EnqueueOptions)) *Batch {
+	b.callback = &batchMember{jobType: jobType, payload: payload, opts: opts}
+	return b
+}
+
+// Len returns the number of member jobs added so far.
+func (b *Batch) Len() int {
+	return len(b.members)
+}
+
+// BatchCallback carries the batch ID into a batch's OnComplete job.
+type BatchCallback struct {
+	BatchID string `json:"batchId"`
+}
+
+// BatchRecord is the progress of a batch. Pending counts members that are
+// queued or processing, including ones waiting for a retry.
+type BatchRecord struct {
+	ID            string     `json:"id"`
+	Description   string     `json:"description,omitempty"`
+	Total         int        `json:"total"`
+	Pending       int        `json:"pending"`
+	Succeeded     int        `json:"succeeded"`
+	Failed        int        `json:"failed"`
+	Cancelled     int        `json:"cancelled"`
+	CallbackType  string     `json:"callbackType,omitempty"`
+	CallbackJobID string     `json:"callbackJobId,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+	FinishedAt    *time.Time `json:"finishedAt,omitempty"`
+}
+
+// Finished reports whether every member has reached a terminal status.
+func (r BatchRecord) Finished() bool {
+	return r.FinishedAt != nil
+}
+
+// EnqueueBatch inserts the batch and all its members in one transaction and
+// returns the batch ID. An empty batch finishes immediately.
+func (s *Store) EnqueueBatch(ctx context.Context, batch *Batch) (string, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return "", fmt.Errorf("begin batch tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	id, err := s.EnqueueBatchTx(ctx, tx, batch)
+	if err != nil {
+		return "", err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return "", fmt.Errorf("commit batch tx: %w", err)
+	}
+	return id, nil
+}
+
+// EnqueueBatchTx is EnqueueBatch on the caller's transaction. tx should be a
+// real transaction: the batch row and its members must commit together.
+func (s *Store) EnqueueBatchTx(ctx context.Context, tx DBTX, batch *Batch) (string, error) {
+	var callbackType, callbackPayload any
+	var callbackOptions EnqueueOptions
+	if batch.callback != nil {
+		payload := batch.callback.payload
+		if payload == nil {
+			payload = struct{}{}
+		}
+		encoded, err := json.Marshal(payload)
+		if err != nil {
+			return "", fmt.Errorf("marshal batch callback payload: %w", err)
+		}
+		callbackOptions = buildEnqueueOptions(batch.callback.opts)
+		callbackType = strings.TrimSpace(batch.callback.jobType)
+		callbackPayload = string(encoded)
+	}
+
+	var batchID string
+	if err := tx.QueryRow(ctx, `
+		INSERT INTO job_batches (description, total, pending, callback_type, callback_payload, callback_queue, callback_priority)
+		VALUES ($1, $2, $2, $3, $4::jsonb, $5, $6)
+		RETURNING id::text
+	`, batch.description, len(batch.members), callbackType, callbackPayload, callbackOptions.Queue, callbackOptions.Priority).Scan(&batchID); err != nil {
+		return "", fmt.Errorf("insert job batch: %w", err)
+	}
+
+	for _, member := range batch.members {
+		options := buildEnqueueOptions(member.opts)
+		if options.UniqueKey != "" {
+			return "", ErrBatchUniqueKey
+		}
+		options.batchID = batchID
+
+		if _, err := enqueueTx(ctx, tx, member.jobType, member.payload, member.runAt, options); err != nil {
+			return "", fmt.Errorf("enqueue batch job: %w", err)
+		}
+	}
+
+	if len(batch.members) == 0 {
+		if err := finishBatch(ctx, tx, batchID); err != nil {
+			return "", err
+		}
+	}
+	return batchID, nil
+}
+
+// GetBatch returns a batch's progress or ErrBatchNotFound.
+func (s *Store) GetBatch(ctx context.Context, batchID string) (BatchRecord, error) {
+	id, ok := db.ParseUUID(batchID)
+	if !ok {
+		return BatchRecord{}, ErrBatchNotFound
+	}
+	var rec BatchRecord
+	err := s.db.QueryRow(ctx, `
+		SELECT id::text, description, total, pending, succeeded, failed, cancelled,
+		       COALESCE(callback_type, ''), COALESCE(callback_job_id::text, ''),
+		       created_at, updated_at, finished_at
+		FROM job_batches
+		WHERE id = $1::uuid
+	`, id).Scan(
+		&rec.ID,
+		&rec.Description,
+		&rec.Total,
+		&rec.Pending,
+		&rec.Succeeded,
+		&rec.Failed,
+		&rec.Cancelled,
+		&rec.CallbackType,
+		&rec.CallbackJobID,
+		&rec.CreatedAt,
+		&rec.UpdatedAt,
+		&rec.FinishedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return BatchRecord{}, ErrBatchNotFound
+	}
+	if err != nil {
+		return BatchRecord{}, fmt.Errorf("get job batch: %w", err)
+	}
+	return rec, nil
+}
+
+// recordBatchOutcome counts a member job reaching a terminal status (one of
+// StatusDone, StatusFailed, StatusCancelled) against its batch, if it has one,
+// and finishes the batch when it was the last pending member. It must run in
+// the transaction that moved the job.
+func recordBatchOutcome(ctx context.Context, db DBTX, jobID string, status string) error {
+	var batchID string
+	var pending int
+	err := db.QueryRow(ctx, `
+		UPDATE job_batches b
+		SET pending = b.pending - 1,
+		    succeeded = b.succeeded + CASE WHEN $2 = 'done' THEN 1 ELSE 0 END,
+		    failed = b.failed + CASE WHEN $2 = 'failed' THEN 1 ELSE 0 END,
+		    cancelled = b.cancelled + CASE WHEN $2 = 'cancelled' THEN 1 ELSE 0 END,
+		    updated_at = now()
+		FROM jobs j
+		WHERE j.id = $1::uuid
+		  AND b.id = j.batch_id
+		  AND b.finished_at IS NULL
+		RETURNING b.id::text, b.pending
+	`, jobID, status).Scan(&batchID, &pending)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("update job batch: %w", err)
+	}
+
+	if pending > 0 {
+		return nil
+	}
+	return finishBatch(ctx, db, batchID)
+}
+
+// finishBatch marks the batch finished and enqueues its callback job, if any.
+func finishBatch(ctx context.Context, tx DBTX, batchID string) error {
+	var callbackType, callbackQueue string
+	var callbackPayload []byte
+	var callbackPriority int
+	err := tx.QueryRow(ctx, `
+		UPDATE job_batches
+		SET finished_at = now(),
+		    updated_at = now()
+		WHERE id = $1::uuid
+		  AND finished_at IS NULL
+		RETURNING
+			COALESCE(callback_type, ''),
+			CASE
+				WHEN jsonb_typeof(callback_payload) = 'object'
+					THEN callback_payload || jsonb_build_object('batchId', id::text)
+				ELSE COALESCE(callback_payload, '{}'::jsonb)
+			END::text,
+			callback_queue,
+			callback_priority
+	`, batchID).Scan(&callbackType, &callbackPayload, &callbackQueue, &callbackPriority)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("finish job batch: %w", err)
+	}
+	if callbackType == "" {
+		return nil
+	}
+
+	callbackID, err := enqueueTx(ctx, tx, callbackType, json.RawMessage(callbackPayload), time.Now().UTC(), EnqueueOptions{
+		UniqueKey:  "batch_callback:" + batchID,
+		OnConflict: OnConflictReturnExisting,
+		Queue:      callbackQueue,
+		Priority:   callbackPriority,
+	})
+	if err != nil {
+		return fmt.Errorf("enqueue batch callback: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE job_batches
+		SET callback_job_id = $2::uuid
+		WHERE id = $1::uuid
+	`, batchID, db.EmptyToNil(callbackID)); err != nil {
+		return fmt.Errorf("record batch callback job: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"saas-core-template/backend/internal/testdb"
+)
+
+func TestBatchCompletionCallback(t *testing.T) {
+	ctx := context.Background()
+	pool := testdb.New(t)
+	store := NewStore(pool)
+	claimer := NewClaimer(pool, ClaimerConfig{WorkerID: "test"})
+
+	type exportDone struct {
+		BatchCallback
+		Report string `json:"report"`
+	}
+
+	now := time.Now().UTC()
+	batch := NewBatch("nightly export").
+		Add("export", map[string]int{"part": 1}, now).
+		Add("export", map[string]int{"part": 2}, now).
+		Add("export", map[string]int{"part": 3}, now).
+		OnComplete("export_done", exportDone{Report: "nightly"})
+	batchID, err := store.EnqueueBatch(ctx, batch)
+	if err != nil {
+		t.Fatalf("enqueue batch: %v", err)
+	}
+
+	claim := func() *Job {
+		t.Helper()
+		job, err := claimer.Claim(ctx, ClaimFilter{})
+		if err != nil || job == nil {
+			t.Fatalf("expected a member job to claim, got %v, %v", job, err)
+		}
+		return job
+	}
+	progress := func() BatchRecord {
+		t.Helper()
+		rec, err := store.GetBatch(ctx, batchID)
+		if err != nil {
+			t.Fatalf("get batch: %v", err)
+		}
+		return rec
+	}
+
+	done := claim()
+	if err := claimer.Complete(ctx, done.ID, done.Attempts); err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+	if rec := progress(); rec.Pending != 2 || rec.Succeeded != 1 || rec.Finished() {
+		t.Fatalf("unexpected progress after one success: %+v", rec)
+	}
+
+	failed := claim()
+	if err := claimer.Fail(ctx, FailureInput{JobID: failed.ID, Attempts: failed.Attempts, MaxAttempts: failed.Attempts, Err: errors.New("boom")}); err != nil {
+		t.Fatalf("fail: %v", err)
+	}
+
+	remaining, err := store.List(ctx, ListFilter{Status: StatusQueued, BatchID: batchID})
+	if err != nil || len(remaining) != 1 {
+		t.Fatalf("expected one queued member, got %v, %v", remaining, err)
+	}
+	if rec := progress(); rec.CallbackJobID != "" {
+		t.Fatalf("expected no callback before the last member finishes, got %+v", rec)
+	}
+	if err := store.Cancel(ctx, remaining[0].ID); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+
+	rec := progress()
+	if !rec.Finished() || rec.Pending != 0 || rec.Succeeded != 1 || rec.Failed != 1 || rec.Cancelled != 1 {
+		t.Fatalf("unexpected final progress: %+v", rec)
+	}
+	if rec.CallbackJobID == "" {
+		t.Fatalf("expected the callback job to be enqueued")
+	}
+
+	callback, err := store.Get(ctx, rec.CallbackJobID)
+	if err != nil {
+		t.Fatalf("get callback job: %v", err)
+	}
+	var payload exportDone
+	if err := json.Unmarshal(callback.Payload, &payload); err != nil {
+		t.Fatalf("decode callback payload: %v", err)
+	}
+	if callback.Type != "export_done" || payload.BatchID != batchID || payload.Report != "nightly" {
+		t.Fatalf("unexpected callback job %s with payload %+v", callback.Type, payload)
+	}
+}
@@ -0,0 +1,53 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	base := time.Date(2025, 3, 14, 10, 17, 30, 0, time.UTC) // Friday
+
+	cases := []struct {
+		name string
+		spec string
+		want time.Time
+	}{
+		{"every interval", "@every 15m", base.Add(15 * time.Minute)},
+		{"hourly", "@hourly", time.Date(2025, 3, 14, 11, 0, 0, 0, time.UTC)},
+		{"daily", "@daily", time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"weekly starts sunday", "@weekly", time.Date(2025, 3, 16, 0, 0, 0, 0, time.UTC)},
+		{"monthly", "@monthly", time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"step minutes", "*/5 * * * *", time.Date(2025, 3, 14, 10, 20, 0, 0, time.UTC)},
+		{"fixed time later today", "30 14 * * *", time.Date(2025, 3, 14, 14, 30, 0, 0, time.UTC)},
+		{"fixed time tomorrow", "0 3 * * *", time.Date(2025, 3, 15, 3, 0, 0, 0, time.UTC)},
+		{"weekday range", "0 9 * * 1-5", time.Date(2025, 3, 17, 9, 0, 0, 0, time.UTC)},
+		{"sunday as 7", "0 0 * * 7", time.Date(2025, 3, 16, 0, 0, 0, 0, time.UTC)},
+		{"list", "0 8,20 * * *", time.Date(2025, 3, 14, 20, 0, 0, 0, time.UTC)},
+		{"day of month or weekday", "0 0 1 * 6", time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC)},
+		// */1 restricts day-of-month, so it is ORed with Monday and matches every day.
+		{"step day of month or weekday", "0 0 */1 * 1", time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"weekday only with star", "0 0 * * 1", time.Date(2025, 3, 17, 0, 0, 0, 0, time.UTC)},
+		{"leap day", "0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			sched, err := ParseSchedule(tc.spec)
+			if err != nil {
+				t.Fatalf("parse %q: %v", tc.spec, err)
+			}
+			if got := sched.Next(base); !got.Equal(tc.want) {
+				t.Fatalf("next for %q: expected %s, got %s", tc.spec, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestParseScheduleRejectsInvalid(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "@every nope", "@every 10ms", "0 0 30 2 *", "0 0 31 4,6,9,11 *"} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Fatalf("expected error for %q", spec)
+		}
+	}
+}
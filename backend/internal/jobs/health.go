@@ -0,0 +1,106 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// HealthHandler serves the worker's /healthz, /readyz and /stats endpoints.
+type HealthHandler struct {
+	db     *pgxpool.Pool
+	store  *Store
+	worker *Worker
+	// staleAfter is how long the worker may go without a successful poll
+	// before it is reported as not ready.
+	staleAfter time.Duration
+}
+
+func NewHealthHandler(db *pgxpool.Pool, store *Store, worker *Worker) *HealthHandler {
+	staleAfter := 5 * worker.cfg.PollInterval
+	if staleAfter < 30*time.Second {
+		staleAfter = 30 * time.Second
+	}
+
+	return &HealthHandler{db: db, store: store, worker: worker, staleAfter: staleAfter}
+}
+
+func (h *HealthHandler) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", h.healthz)
+	mux.HandleFunc("GET /readyz", h.readyz)
+	mux.HandleFunc("GET /stats", h.stats)
+	return mux
+}
+
+func (h *HealthHandler) healthz(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (h *HealthHandler) readyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+	defer cancel()
+
+	lastPoll := h.worker.LastPoll()
+	body := map[string]any{"lastPollAt": nil}
+	if !lastPoll.IsZero() {
+		body["lastPollAt"] = lastPoll.UTC().Format(time.RFC3339)
+	}
+
+	if err := h.db.Ping(ctx); err != nil {
+		body["status"] = "not_ready"
+		body["reason"] = "database_unreachable"
+		writeJSON(w, http.StatusServiceUnavailable, body)
+		return
+	}
+
+	if lastPoll.IsZero() || time.Since(lastPoll) > h.staleAfter {
+		body["status"] = "not_ready"
+		body["reason"] = "poll_stale"
+		writeJSON(w, http.StatusServiceUnavailable, body)
+		return
+	}
+
+	body["status"] = "ready"
+	writeJSON(w, http.StatusOK, body)
+}
+
+func (h *HealthHandler) stats(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	stats, err := h.store.Stats(ctx)
+	if err != nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "stats_unavailable"})
+		return
+	}
+
+	types := make([]map[string]any, 0, len(stats.Types))
+	for _, ts := range stats.Types {
+		types = append(types, map[string]any{
+			"type":                   ts.Type,
+			"queued":                 ts.Queued,
+			"processing":             ts.Processing,
+			"failed":                 ts.Failed,
+			"oldestQueuedAgeSeconds": int64(ts.OldestQueuedAge.Seconds()),
+		})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"queued":                 stats.Queued,
+		"processing":             stats.Processing,
+		"failed":                 stats.Failed,
+		"oldestQueuedAgeSeconds": int64(stats.OldestQueuedAge.Seconds()),
+		"running":                h.worker.Running(),
+		"types":                  types,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(payload)
+}
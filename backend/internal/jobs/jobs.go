@@ -8,11 +8,27 @@ import (
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"saas-core-template/backend/internal/db"
 )
 
+// DBTX is the query surface shared by *pgxpool.Pool, *pgx.Conn and pgx.Tx.
+type DBTX interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
 type Enqueuer interface {
-	Enqueue(ctx context.Context, jobType string, payload any, runAt time.Time) (string, error)
+	Enqueue(ctx context.Context, jobType string, payload any, runAt time.Time, opts ...func(*EnqueueOptions)) (string, error)
+}
+
+// TxEnqueuer inserts jobs on the caller's transaction, so a job is only
+// visible to workers if the writes it belongs to commit (the outbox pattern).
+type TxEnqueuer interface {
+	Enqueuer
+	EnqueueTx(ctx context.Context, tx DBTX, jobType string, payload any, runAt time.Time, opts ...func(*EnqueueOptions)) (string, error)
 }
 
 type Store struct {
@@ -23,42 +39,149 @@ func NewStore(db *pgxpool.Pool) *Store {
 	return &Store{db: db}
 }
 
-func (s *Store) Enqueue(ctx context.Context, jobType string, payload any, runAt time.Time) (string, error) {
+func (s *Store) Enqueue(ctx context.Context, jobType string, payload any, runAt time.Time, opts ...func(*EnqueueOptions)) (string, error) {
+	return s.EnqueueTx(ctx, s.db, jobType, payload, runAt, opts...)
+}
+
+// EnqueueTx inserts a job using tx. Listening workers are notified when tx
+// commits; nothing is enqueued if it rolls back.
+//
+// With WithUniqueKey, a queued or processing job holding the same key is handled
+// according to its ConflictAction; see there for the returned ID in each case.
+func (s *Store) EnqueueTx(ctx context.Context, tx DBTX, jobType string, payload any, runAt time.Time, opts ...func(*EnqueueOptions)) (string, error) {
+	return enqueueTx(ctx, tx, jobType, payload, runAt, buildEnqueueOptions(opts))
+}
+
+func enqueueTx(ctx context.Context, tx DBTX, jobType string, payload any, runAt time.Time, options EnqueueOptions) (string, error) {
 	encoded, err := json.Marshal(payload)
 	if err != nil {
 		return "", fmt.Errorf("marshal job payload: %w", err)
 	}
 
+	metadata, err := json.Marshal(jobMetadata(ctx))
+	if err != nil {
+		return "", fmt.Errorf("marshal job metadata: %w", err)
+	}
+
+	// A conflicting job can finish between the insert and the lookup, so retry a few times.
+	for i := 0; i < 3; i++ {
+		id, inserted, err := insertJob(ctx, tx, jobType, string(encoded), string(metadata), runAt.UTC(), options)
+		if err != nil {
+			return "", err
+		}
+		if !inserted {
+			if options.OnConflict == OnConflictSkip {
+				return "", nil
+			}
+			id, err = resolveConflict(ctx, tx, jobType, string(encoded), string(metadata), runAt.UTC(), options)
+			if err != nil {
+				return "", err
+			}
+			if id == "" {
+				continue
+			}
+		}
+
+		if err := notifyListeners(ctx, tx, jobType, id); err != nil {
+			return "", err
+		}
+
+		return id, nil
+	}
+
+	return "", fmt.Errorf("enqueue job with unique key %q: conflict did not resolve", options.UniqueKey)
+}
+
+func insertJob(ctx context.Context, tx DBTX, jobType string, payload string, metadata string, runAt time.Time, options EnqueueOptions) (string, bool, error) {
 	var id string
-	if err := s.db.QueryRow(ctx, `
-		INSERT INTO jobs (type, payload, status, run_at)
-		VALUES ($1, $2::jsonb, 'queued', $3)
+	err := tx.QueryRow(ctx, `
+		INSERT INTO jobs (type, payload, status, run_at, unique_key, queue, priority, metadata, batch_id)
+		VALUES ($1, $2::jsonb, 'queued', $3, $4, $5, $6, $7::jsonb, $8::uuid)
+		ON CONFLICT (unique_key) WHERE unique_key IS NOT NULL AND status IN ('queued', 'processing') DO NOTHING
 		RETURNING id::text
-	`, jobType, string(encoded), runAt.UTC()).Scan(&id); err != nil {
-		return "", fmt.Errorf("insert job: %w", err)
+	`, jobType, payload, runAt, db.EmptyToNil(options.UniqueKey), options.Queue, options.Priority, metadata, db.EmptyToNil(options.batchID)).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("insert job: %w", err)
 	}
+	return id, true, nil
+}
 
+// resolveConflict applies options.OnConflict to the active job holding the unique key.
+// It returns an empty ID when that job is no longer active.
+func resolveConflict(ctx context.Context, tx DBTX, jobType string, payload string, metadata string, runAt time.Time, options EnqueueOptions) (string, error) {
+	var id string
+	if options.OnConflict == OnConflictReplace {
+		err := tx.QueryRow(ctx, `
+			UPDATE jobs
+			SET type = $2,
+			    payload = $3::jsonb,
+			    run_at = $4,
+			    queue = $5,
+			    priority = $6,
+			    metadata = $7::jsonb,
+			    updated_at = now()
+			WHERE unique_key = $1 AND status = 'queued'
+			RETURNING id::text
+		`, options.UniqueKey, jobType, payload, runAt, options.Queue, options.Priority, metadata).Scan(&id)
+		if err == nil {
+			return id, nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return "", fmt.Errorf("replace job: %w", err)
+		}
+		// The job is already processing; it keeps its payload and we report its ID.
+	}
+
+	err := tx.QueryRow(ctx, `
+		SELECT id::text
+		FROM jobs
+		WHERE unique_key = $1 AND status IN ('queued', 'processing')
+	`, options.UniqueKey).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("load existing job: %w", err)
+	}
 	return id, nil
 }
 
 type Job struct {
 	ID          string
 	Type        string
+	Queue       string
+	Priority    int
 	PayloadJSON []byte
 	Attempts    int
 	MaxAttempts int
+	// Metadata carries enqueue-time context such as the W3C traceparent.
+	Metadata map[string]string
 }
 
 type Claimer struct {
-	db        *pgxpool.Pool
-	workerID  string
-	lockTTL   time.Duration
-	maxJitter time.Duration
+	db       *pgxpool.Pool
+	workerID string
+	lockTTL  time.Duration
+
+	rateLimiter RateLimiter
+	rateLimits  map[string]RateLimit
+	failOpen    bool
+	throttles   *throttles
 }
 
 type ClaimerConfig struct {
 	WorkerID string
 	LockTTL  time.Duration
+	// RateLimiter enforces RateLimits across workers. Without it, rate limits are ignored.
+	RateLimiter RateLimiter
+	// RateLimits caps how often jobs of each type may be claimed; see Registry.RateLimits.
+	RateLimits map[string]RateLimit
+	// RateLimitFailOpen lets jobs run unthrottled while the RateLimiter returns
+	// errors. By default their type is held back until the limiter recovers.
+	RateLimitFailOpen bool
 }
 
 func NewClaimer(db *pgxpool.Pool, cfg ClaimerConfig) *Claimer {
@@ -68,20 +191,61 @@ func NewClaimer(db *pgxpool.Pool, cfg ClaimerConfig) *Claimer {
 	}
 
 	return &Claimer{
-		db:       db,
-		workerID: cfg.WorkerID,
-		lockTTL:  lockTTL,
+		db:          db,
+		workerID:    cfg.WorkerID,
+		lockTTL:     lockTTL,
+		rateLimiter: cfg.RateLimiter,
+		rateLimits:  cfg.RateLimits,
+		failOpen:    cfg.RateLimitFailOpen,
+		throttles:   newThrottles(),
 	}
 }
 
+// ClaimFilter narrows which jobs Claim may pick up.
+type ClaimFilter struct {
+	// ExcludeTypes lists job types the caller has no capacity for right now.
+	ExcludeTypes []string
+	// Queues limits claiming to the named queues; empty means any queue, in
+	// which case priority is compared across queues.
+	Queues []string
+}
+
 func (c *Claimer) ClaimNext(ctx context.Context) (*Job, error) {
+	return c.Claim(ctx, ClaimFilter{})
+}
+
+// Claim locks the next runnable job matching filter, or returns nil when there is none.
+// Job types over their rate limit are skipped and left queued.
+func (c *Claimer) Claim(ctx context.Context, filter ClaimFilter) (*Job, error) {
+	queues := nonNilStrings(filter.Queues)
+	// Types throttled during this call stay excluded even if their throttle
+	// window has already passed, so a short window cannot make the loop spin.
+	var throttledTypes []string
+
+	for {
+		excludeTypes := append(nonNilStrings(filter.ExcludeTypes), c.throttles.active(time.Now())...)
+		excludeTypes = append(excludeTypes, throttledTypes...)
+
+		job, throttledType, err := c.claimOnce(ctx, excludeTypes, queues)
+		if err != nil || throttledType == "" {
+			return job, err
+		}
+		throttledTypes = append(throttledTypes, throttledType)
+	}
+}
+
+// claimOnce claims one job. If the job's type is over its rate limit, the claim
+// is rolled back, leaving the job queued with its attempts untouched, and the
+// throttled type is returned.
+func (c *Claimer) claimOnce(ctx context.Context, excludeTypes []string, queues []string) (_ *Job, throttledType string, _ error) {
 	tx, err := c.db.Begin(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("begin claim tx: %w", err)
+		return nil, "", fmt.Errorf("begin claim tx: %w", err)
 	}
 	defer tx.Rollback(ctx)
 
 	var job Job
+	var metadata []byte
 	err = tx.QueryRow(ctx, `
 		WITH next_job AS (
 			SELECT id
@@ -89,7 +253,9 @@ func (c *Claimer) ClaimNext(ctx context.Context) (*Job, error) {
 			WHERE status = 'queued'
 			  AND run_at <= now()
 			  AND (locked_until IS NULL OR locked_until < now())
-			ORDER BY run_at ASC, created_at ASC
+			  AND NOT (type = ANY($3::text[]))
+			  AND (cardinality($4::text[]) = 0 OR queue = ANY($4::text[]))
+			ORDER BY priority DESC, run_at ASC, created_at ASC
 			LIMIT 1
 			FOR UPDATE SKIP LOCKED
 		)
@@ -100,53 +266,212 @@ func (c *Claimer) ClaimNext(ctx context.Context) (*Job, error) {
 		    locked_by = $2,
 		    updated_at = now()
 		WHERE id IN (SELECT id FROM next_job)
-		RETURNING id::text, type, payload::text, attempts, max_attempts
-	`, int(c.lockTTL.Seconds()), c.workerID).Scan(&job.ID, &job.Type, &job.PayloadJSON, &job.Attempts, &job.MaxAttempts)
+		RETURNING id::text, type, queue, priority, payload::text, metadata::text, attempts, max_attempts
+	`, int(c.lockTTL.Seconds()), c.workerID, excludeTypes, queues).Scan(&job.ID, &job.Type, &job.Queue, &job.Priority, &job.PayloadJSON, &metadata, &job.Attempts, &job.MaxAttempts)
 
 	if errors.Is(err, pgx.ErrNoRows) {
-		return nil, nil
+		return nil, "", nil
 	}
 	if err != nil {
-		return nil, fmt.Errorf("claim job: %w", err)
+		return nil, "", fmt.Errorf("claim job: %w", err)
+	}
+	// Metadata is best effort; a job without trace context still runs.
+	_ = json.Unmarshal(metadata, &job.Metadata)
+
+	if !c.allow(ctx, job.Type) {
+		return nil, job.Type, nil
+	}
+
+	if err := startAttempt(ctx, tx, job.ID, c.workerID); err != nil {
+		return nil, "", err
 	}
 
 	if err := tx.Commit(ctx); err != nil {
-		return nil, fmt.Errorf("commit claim tx: %w", err)
+		return nil, "", fmt.Errorf("commit claim tx: %w", err)
 	}
 
-	return &job, nil
+	return &job, "", nil
 }
 
-func (c *Claimer) Complete(ctx context.Context, jobID string) error {
-	_, err := c.db.Exec(ctx, `
+// ErrLockLost is returned when a job is no longer processing under this
+// claimer's lock, typically because the reaper reclaimed it and another
+// worker picked it up.
+var ErrLockLost = errors.New("job lock lost")
+
+// Extend pushes the job's lock another LockTTL into the future. Workers call
+// it periodically while a handler runs.
+//
+// Ownership is checked on attempts as well as locked_by: every claim bumps
+// attempts, so a stale worker cannot touch a job that was reclaimed and
+// claimed again, even by a process sharing its worker ID.
+func (c *Claimer) Extend(ctx context.Context, jobID string, attempts int) error {
+	tag, err := c.db.Exec(ctx, `
+		UPDATE jobs
+		SET locked_until = now() + ($2::int * interval '1 second'),
+		    updated_at = now()
+		WHERE id = $1
+		  AND status = 'processing'
+		  AND locked_by = $3
+		  AND attempts = $4
+	`, jobID, int(c.lockTTL.Seconds()), c.workerID, attempts)
+	if err != nil {
+		return fmt.Errorf("extend job lock: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrLockLost
+	}
+	return nil
+}
+
+func (c *Claimer) Complete(ctx context.Context, jobID string, attempts int) error {
+	tx, err := c.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin complete tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
 		UPDATE jobs
 		SET status = 'done',
 		    locked_until = NULL,
 		    locked_by = NULL,
 		    updated_at = now()
 		WHERE id = $1
-	`, jobID)
+		  AND status = 'processing'
+		  AND locked_by = $2
+		  AND attempts = $3
+	`, jobID, c.workerID, attempts)
 	if err != nil {
 		return fmt.Errorf("complete job: %w", err)
 	}
+	if tag.RowsAffected() == 0 {
+		return ErrLockLost
+	}
+
+	if err := finishAttempt(ctx, tx, jobID, AttemptSucceeded, nil); err != nil {
+		return err
+	}
+	if err := recordBatchOutcome(ctx, tx, jobID, StatusDone); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit complete tx: %w", err)
+	}
 	return nil
 }
 
 type FailureInput struct {
-	JobID       string
+	JobID string
+	// Attempts is the job's attempt count as claimed; it must still match for
+	// the failure to be recorded.
 	Attempts    int
 	MaxAttempts int
-	Err         error
+	// RetryDelay is the wait before the next attempt; zero uses the default backoff.
+	RetryDelay time.Duration
+	// Err is recorded as last_error. A PermanentError fails the job without retrying.
+	Err error
 }
 
 func (c *Claimer) Fail(ctx context.Context, input FailureInput) error {
-	status := "queued"
-	nextRunAt := time.Now().UTC().Add(backoff(input.Attempts))
-	if input.Attempts >= input.MaxAttempts {
-		status = "failed"
-		nextRunAt = time.Now().UTC()
+	tx, err := c.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin fail tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := failJob(ctx, tx, input, c.workerID, time.Now().UTC()); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit fail tx: %w", err)
+	}
+	return nil
+}
+
+// ErrLockExpired is recorded on jobs whose lock ran out while they were still
+// processing, which usually means the worker holding them crashed or was killed.
+var ErrLockExpired = errors.New("lock expired while processing")
+
+// ReclaimExpired returns up to limit jobs stuck in "processing" with an
+// expired lock to the queue, or marks them failed once they are out of
+// attempts. The claim that was in flight counts as a failed attempt.
+// maxAttempts, when set, returns the attempt limit for a job, e.g.
+// Registry.MaxAttempts so handler overrides apply as they do on failure.
+func (c *Claimer) ReclaimExpired(ctx context.Context, limit int, maxAttempts func(*Job) int) (int, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+
+	tx, err := c.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("begin reclaim tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+		SELECT id::text, type, attempts, max_attempts, COALESCE(locked_by, '')
+		FROM jobs
+		WHERE status = 'processing'
+		  AND locked_until < now()
+		ORDER BY locked_until ASC
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	`, limit)
+	if err != nil {
+		return 0, fmt.Errorf("select expired jobs: %w", err)
+	}
+
+	type expiredJob struct {
+		input    FailureInput
+		lockedBy string
+	}
+	var expired []expiredJob
+	for rows.Next() {
+		var job expiredJob
+		var stored Job
+		if err := rows.Scan(&stored.ID, &stored.Type, &stored.Attempts, &stored.MaxAttempts, &job.lockedBy); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("scan expired job: %w", err)
+		}
+		job.input.JobID = stored.ID
+		job.input.Attempts = stored.Attempts
+		job.input.MaxAttempts = stored.MaxAttempts
+		if maxAttempts != nil {
+			job.input.MaxAttempts = maxAttempts(&stored)
+		}
+		job.input.Err = fmt.Errorf("%w (locked by %q)", ErrLockExpired, job.lockedBy)
+		expired = append(expired, job)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("select expired jobs rows: %w", err)
+	}
+
+	now := time.Now().UTC()
+	for _, job := range expired {
+		if err := failJob(ctx, tx, job.input, job.lockedBy, now); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("commit reclaim tx: %w", err)
 	}
 
+	return len(expired), nil
+}
+
+// failJob records a failed attempt for a job still locked by lockedBy on the
+// attempt in input.
+func failJob(ctx context.Context, db DBTX, input FailureInput, lockedBy string, now time.Time) error {
+	maxAttempts := input.MaxAttempts
+	if IsPermanent(input.Err) {
+		maxAttempts = input.Attempts
+	}
+	status, nextRunAt := nextAttempt(input.Attempts, maxAttempts, input.RetryDelay, now)
+
 	lastErr := ""
 	if input.Err != nil {
 		lastErr = input.Err.Error()
@@ -155,7 +480,7 @@ func (c *Claimer) Fail(ctx context.Context, input FailureInput) error {
 		}
 	}
 
-	_, err := c.db.Exec(ctx, `
+	tag, err := db.Exec(ctx, `
 		UPDATE jobs
 		SET status = $1,
 		    run_at = $2,
@@ -164,13 +489,44 @@ func (c *Claimer) Fail(ctx context.Context, input FailureInput) error {
 		    last_error = $3,
 		    updated_at = now()
 		WHERE id = $4
-	`, status, nextRunAt, lastErr, input.JobID)
+		  AND status = 'processing'
+		  AND COALESCE(locked_by, '') = $5
+		  AND attempts = $6
+	`, status, nextRunAt, lastErr, input.JobID, lockedBy, input.Attempts)
 	if err != nil {
 		return fmt.Errorf("fail job: %w", err)
 	}
+	if tag.RowsAffected() == 0 {
+		return ErrLockLost
+	}
+
+	outcome := AttemptFailed
+	if errors.Is(input.Err, ErrLockExpired) {
+		outcome = AttemptLockExpired
+	}
+	if err := finishAttempt(ctx, db, input.JobID, outcome, input.Err); err != nil {
+		return err
+	}
+
+	if status == StatusFailed {
+		return recordBatchOutcome(ctx, db, input.JobID, StatusFailed)
+	}
 	return nil
 }
 
+// nextAttempt decides whether a failed job is retried (and when) or parked as failed.
+// A positive retryDelay overrides the default backoff.
+func nextAttempt(attempts int, maxAttempts int, retryDelay time.Duration, now time.Time) (string, time.Time) {
+	if attempts >= maxAttempts {
+		return StatusFailed, now
+	}
+	if retryDelay <= 0 {
+		retryDelay = backoff(attempts)
+	}
+	return StatusQueued, now.Add(retryDelay)
+}
+
+// backoff is the retry delay for job types without a RetryPolicy.
 func backoff(attempt int) time.Duration {
 	// attempt is 1-based here, because we increment attempts on claim.
 	if attempt <= 1 {
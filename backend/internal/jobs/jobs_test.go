@@ -0,0 +1,109 @@
+package jobs
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNextAttempt(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("requeues with backoff while attempts remain", func(t *testing.T) {
+		status, runAt := nextAttempt(1, 10, 0, now)
+		if status != "queued" {
+			t.Fatalf("expected queued, got %q", status)
+		}
+		if !runAt.Equal(now.Add(5 * time.Second)) {
+			t.Fatalf("unexpected run_at %s", runAt)
+		}
+	})
+
+	t.Run("fails once max attempts is reached", func(t *testing.T) {
+		status, runAt := nextAttempt(10, 10, 0, now)
+		if status != "failed" {
+			t.Fatalf("expected failed, got %q", status)
+		}
+		if !runAt.Equal(now) {
+			t.Fatalf("unexpected run_at %s", runAt)
+		}
+	})
+
+	t.Run("uses an explicit retry delay", func(t *testing.T) {
+		_, runAt := nextAttempt(2, 10, time.Minute, now)
+		if !runAt.Equal(now.Add(time.Minute)) {
+			t.Fatalf("unexpected run_at %s", runAt)
+		}
+	})
+
+	t.Run("fails when attempts exceed max", func(t *testing.T) {
+		if status, _ := nextAttempt(3, 2, 0, now); status != "failed" {
+			t.Fatalf("expected failed, got %q", status)
+		}
+	})
+}
+
+func TestBackoff(t *testing.T) {
+	t.Run("first retry is short", func(t *testing.T) {
+		if got := backoff(1); got != 5*time.Second {
+			t.Fatalf("expected 5s, got %s", got)
+		}
+	})
+
+	t.Run("grows exponentially", func(t *testing.T) {
+		if got := backoff(3); got != 8*time.Second {
+			t.Fatalf("expected 8s, got %s", got)
+		}
+	})
+
+	t.Run("is capped", func(t *testing.T) {
+		if got := backoff(50); got > 10*time.Minute {
+			t.Fatalf("expected cap of 10m, got %s", got)
+		}
+	})
+}
+
+func TestBuildEnqueueOptions(t *testing.T) {
+	t.Run("defaults to no unique key", func(t *testing.T) {
+		options := buildEnqueueOptions(nil)
+		if options.UniqueKey != "" {
+			t.Fatalf("expected empty unique key, got %q", options.UniqueKey)
+		}
+		if options.Queue != DefaultQueue || options.Priority != PriorityDefault {
+			t.Fatalf("expected default queue and priority, got %q/%d", options.Queue, options.Priority)
+		}
+	})
+
+	t.Run("applies queue and priority", func(t *testing.T) {
+		options := buildEnqueueOptions([]func(*EnqueueOptions){WithQueue(" critical "), WithPriority(PriorityHigh)})
+		if options.Queue != "critical" {
+			t.Fatalf("expected trimmed queue, got %q", options.Queue)
+		}
+		if options.Priority != PriorityHigh {
+			t.Fatalf("expected priority %d, got %d", PriorityHigh, options.Priority)
+		}
+	})
+
+	t.Run("applies unique key and conflict action", func(t *testing.T) {
+		options := buildEnqueueOptions([]func(*EnqueueOptions){WithUniqueKey("  welcome_email:123 ", OnConflictReturnExisting)})
+		if options.UniqueKey != "welcome_email:123" {
+			t.Fatalf("expected trimmed key, got %q", options.UniqueKey)
+		}
+		if options.OnConflict != OnConflictReturnExisting {
+			t.Fatalf("expected OnConflictReturnExisting, got %v", options.OnConflict)
+		}
+	})
+}
+
+func TestSelectorIDs(t *testing.T) {
+	ids, ok := selectorIDs([]string{" 7F1C2A4E-3B5D-4C6E-8F90-A1B2C3D4E5F6 ", "42", ""})
+	if !ok || !reflect.DeepEqual(ids, []string{"7f1c2a4e-3b5d-4c6e-8f90-a1b2c3d4e5f6"}) {
+		t.Fatalf("unexpected ids %v, %v", ids, ok)
+	}
+	if ids, ok := selectorIDs(nil); !ok || len(ids) != 0 {
+		t.Fatalf("expected no IDs to select by other fields, got %v, %v", ids, ok)
+	}
+	if _, ok := selectorIDs([]string{"42"}); ok {
+		t.Fatalf("expected only invalid IDs to match nothing")
+	}
+}
@@ -0,0 +1,125 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"saas-core-template/backend/internal/db"
+)
+
+// ErrNotQueued is returned when a job can no longer be cancelled or
+// rescheduled because a worker has already picked it up or it has finished.
+var ErrNotQueued = errors.New("job is not queued")
+
+// Cancel moves a queued job to the cancelled status so no worker claims it.
+// Cancelling an already cancelled job is a no-op. It returns ErrNotFound for
+// unknown IDs and ErrNotQueued for jobs that are processing or finished.
+func (s *Store) Cancel(ctx context.Context, jobID string) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin cancel tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := s.CancelTx(ctx, tx, jobID); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit cancel tx: %w", err)
+	}
+	return nil
+}
+
+// CancelTx is Cancel on the caller's transaction, so a job can be withdrawn
+// together with the domain write that makes it obsolete. tx should be a real
+// transaction when the job may belong to a batch.
+func (s *Store) CancelTx(ctx context.Context, tx DBTX, jobID string) error {
+	jobID, ok := db.ParseUUID(jobID)
+	if !ok {
+		return ErrNotFound
+	}
+	status, err := updateQueuedJob(ctx, tx, jobID, `status = 'cancelled'`)
+	if err != nil {
+		return fmt.Errorf("cancel job: %w", err)
+	}
+	switch status {
+	case StatusQueued:
+		return recordBatchOutcome(ctx, tx, jobID, StatusCancelled)
+	case StatusCancelled:
+		return nil
+	default:
+		return ErrNotQueued
+	}
+}
+
+// Reschedule moves a queued job's run_at. It returns ErrNotFound for unknown
+// IDs and ErrNotQueued for jobs that are processing, finished or cancelled.
+func (s *Store) Reschedule(ctx context.Context, jobID string, runAt time.Time) error {
+	return s.RescheduleTx(ctx, s.db, jobID, runAt)
+}
+
+// RescheduleTx is Reschedule on the caller's transaction.
+func (s *Store) RescheduleTx(ctx context.Context, tx DBTX, jobID string, runAt time.Time) error {
+	status, err := updateQueuedJob(ctx, tx, jobID, `run_at = $2`, runAt.UTC())
+	if err != nil {
+		return fmt.Errorf("reschedule job: %w", err)
+	}
+	if status != StatusQueued {
+		return ErrNotQueued
+	}
+
+	// Moving a job earlier should not wait for the next poll.
+	return notifyListeners(ctx, tx, "reschedule", jobID)
+}
+
+// GetByUniqueKey returns the active (queued or processing) job holding key, or ErrNotFound.
+func (s *Store) GetByUniqueKey(ctx context.Context, key string) (JobRecord, error) {
+	rec, err := scanJobRecord(s.db.QueryRow(ctx, `
+		SELECT `+jobRecordColumns+`
+		FROM jobs
+		WHERE unique_key = $1
+		  AND status IN ('queued', 'processing')
+	`, strings.TrimSpace(key)))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return JobRecord{}, ErrNotFound
+	}
+	if err != nil {
+		return JobRecord{}, fmt.Errorf("get job by unique key: %w", err)
+	}
+	return rec, nil
+}
+
+// updateQueuedJob applies set to the job if it is queued and returns the status
+// the job had before the update. $1 is the job ID; extra args start at $2.
+func updateQueuedJob(ctx context.Context, tx DBTX, jobID string, set string, args ...any) (string, error) {
+	id, ok := db.ParseUUID(jobID)
+	if !ok {
+		return "", ErrNotFound
+	}
+	var status string
+	err := tx.QueryRow(ctx, `
+		WITH target AS (
+			SELECT id, status
+			FROM jobs
+			WHERE id = $1::uuid
+			FOR UPDATE
+		), updated AS (
+			UPDATE jobs
+			SET `+set+`,
+			    updated_at = now()
+			WHERE id IN (SELECT id FROM target WHERE status = 'queued')
+		)
+		SELECT status FROM target
+	`, append([]any{id}, args...)...).Scan(&status)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return status, nil
+}
@@ -0,0 +1,74 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"saas-core-template/backend/internal/testdb"
+)
+
+func TestCancelAndReschedule(t *testing.T) {
+	ctx := context.Background()
+	pool := testdb.New(t)
+	store := NewStore(pool)
+
+	enqueue := func(opts ...func(*EnqueueOptions)) string {
+		t.Helper()
+		id, err := store.Enqueue(ctx, "export", map[string]string{}, time.Now().UTC(), opts...)
+		if err != nil {
+			t.Fatalf("enqueue: %v", err)
+		}
+		return id
+	}
+
+	queued := enqueue(WithUniqueKey("export:1", OnConflictSkip))
+	rec, err := store.GetByUniqueKey(ctx, "export:1")
+	if err != nil || rec.ID != queued {
+		t.Fatalf("expected %s by unique key, got %+v, %v", queued, rec, err)
+	}
+
+	later := time.Now().UTC().Add(time.Hour).Truncate(time.Microsecond)
+	if err := store.Reschedule(ctx, queued, later); err != nil {
+		t.Fatalf("reschedule: %v", err)
+	}
+	if rec, err := store.Get(ctx, queued); err != nil || !rec.RunAt.Equal(later) {
+		t.Fatalf("expected run_at %s, got %+v, %v", later, rec, err)
+	}
+
+	if err := store.Cancel(ctx, queued); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if err := store.Cancel(ctx, queued); err != nil {
+		t.Fatalf("expected cancelling twice to be a no-op, got %v", err)
+	}
+	if err := store.Reschedule(ctx, queued, later); !errors.Is(err, ErrNotQueued) {
+		t.Fatalf("expected ErrNotQueued rescheduling a cancelled job, got %v", err)
+	}
+	if _, err := store.GetByUniqueKey(ctx, "export:1"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected a cancelled job to release its unique key, got %v", err)
+	}
+
+	processing := enqueue()
+	if _, err := pool.Exec(ctx, `UPDATE jobs SET status = 'processing' WHERE id = $1::uuid`, processing); err != nil {
+		t.Fatalf("start job: %v", err)
+	}
+	if err := store.Cancel(ctx, processing); !errors.Is(err, ErrNotQueued) {
+		t.Fatalf("expected ErrNotQueued cancelling a processing job, got %v", err)
+	}
+
+	for _, id := range []string{"00000000-0000-0000-0000-000000000000", "42"} {
+		if err := store.Cancel(ctx, id); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("expected ErrNotFound cancelling %q, got %v", id, err)
+		}
+		if err := store.Reschedule(ctx, id, later); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("expected ErrNotFound rescheduling %q, got %v", id, err)
+		}
+	}
+
+	// Malformed IDs in a bulk selector match nothing rather than every job.
+	if n, err := store.CancelQueued(ctx, Selector{IDs: []string{"42"}}); err != nil || n != 0 {
+		t.Fatalf("expected no jobs cancelled for a malformed ID, got %d, %v", n, err)
+	}
+}
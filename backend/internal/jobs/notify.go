@@ -0,0 +1,111 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// NotifyChannel is the Postgres channel Enqueue notifies after inserting a job.
+const NotifyChannel = "jobs_enqueued"
+
+// notifyListeners wakes listening workers; inside a transaction Postgres
+// delivers the notification on commit. On a pgx.Tx the notify runs in a
+// savepoint, because a failed statement would otherwise abort the caller's
+// transaction. A notify that fails cleanly is only logged: the job is then
+// picked up on the next poll. An error is returned only when the transaction
+// may be unusable.
+func notifyListeners(ctx context.Context, tx DBTX, payload string, jobID string) error {
+	const query = `SELECT pg_notify($1, $2)`
+
+	outer, ok := tx.(pgx.Tx)
+	if !ok {
+		if _, err := tx.Exec(ctx, query, NotifyChannel, payload); err != nil {
+			slog.Warn("failed to notify job listeners", "job_id", jobID, "error", err)
+		}
+		return nil
+	}
+
+	sp, err := outer.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin notify savepoint: %w", err)
+	}
+	if _, err := sp.Exec(ctx, query, NotifyChannel, payload); err != nil {
+		if rbErr := sp.Rollback(ctx); rbErr != nil {
+			return fmt.Errorf("roll back notify savepoint: %w", rbErr)
+		}
+		slog.Warn("failed to notify job listeners", "job_id", jobID, "error", err)
+		return nil
+	}
+	if err := sp.Commit(ctx); err != nil {
+		return fmt.Errorf("release notify savepoint: %w", err)
+	}
+	return nil
+}
+
+const maxListenRetryDelay = 30 * time.Second
+
+// Listener wakes a worker as soon as a job is enqueued, using LISTEN on a
+// dedicated connection. It only reduces latency: while the connection is down
+// the worker keeps finding jobs by polling.
+type Listener struct {
+	db       *pgxpool.Pool
+	onNotify func()
+}
+
+func NewListener(db *pgxpool.Pool, onNotify func()) *Listener {
+	return &Listener{db: db, onNotify: onNotify}
+}
+
+// Run listens until ctx is cancelled, reconnecting with backoff after failures.
+func (l *Listener) Run(ctx context.Context) {
+	delay := time.Second
+	for {
+		err := l.listen(ctx, func() { delay = time.Second })
+		if ctx.Err() != nil {
+			return
+		}
+
+		slog.Warn("jobs listener disconnected; falling back to polling", "error", err, "retry_in", delay.String())
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		if delay > maxListenRetryDelay {
+			delay = maxListenRetryDelay
+		}
+	}
+}
+
+func (l *Listener) listen(ctx context.Context, connected func()) error {
+	// LISTEN is connection state, so use a connection the pool will never hand out.
+	conn, err := pgx.ConnectConfig(ctx, l.db.Config().ConnConfig.Copy())
+	if err != nil {
+		return fmt.Errorf("connect listener: %w", err)
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{NotifyChannel}.Sanitize()); err != nil {
+		return fmt.Errorf("listen %s: %w", NotifyChannel, err)
+	}
+
+	connected()
+	slog.Info("jobs listener connected", "channel", NotifyChannel)
+
+	// Catch up on anything enqueued while we were not listening.
+	l.onNotify()
+
+	for {
+		if _, err := conn.WaitForNotification(ctx); err != nil {
+			return fmt.Errorf("wait for notification: %w", err)
+		}
+		l.onNotify()
+	}
+}
@@ -0,0 +1,39 @@
+package jobs
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"saas-core-template/backend/internal/testdb"
+)
+
+func TestEnqueueTxSurvivesFailedNotify(t *testing.T) {
+	ctx := context.Background()
+	pool := testdb.New(t)
+	store := NewStore(pool)
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// pg_notify rejects payloads of 8000 bytes or more, and the job type is the payload.
+	jobType := strings.Repeat("x", 8000)
+	id, err := store.EnqueueTx(ctx, tx, jobType, map[string]string{}, time.Now().UTC())
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	if _, err := tx.Exec(ctx, `SELECT 1`); err != nil {
+		t.Fatalf("expected the caller's transaction to stay usable, got %v", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	if _, err := store.Get(ctx, id); err != nil {
+		t.Fatalf("expected the job to be committed, got %v", err)
+	}
+}
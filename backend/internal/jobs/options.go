@@ -0,0 +1,75 @@
+package jobs
+
+import "strings"
+
+// ConflictAction decides what Enqueue does when an active (queued or processing)
+// job already holds the same unique key.
+type ConflictAction int
+
+const (
+	// OnConflictSkip leaves the existing job alone and returns an empty ID.
+	OnConflictSkip ConflictAction = iota
+	// OnConflictReplace overwrites the payload and run_at of the existing job while it
+	// is still queued and returns its ID. A job that is already processing is left as is.
+	OnConflictReplace
+	// OnConflictReturnExisting leaves the existing job alone and returns its ID.
+	OnConflictReturnExisting
+)
+
+// DefaultQueue is used for jobs enqueued without WithQueue.
+const DefaultQueue = "default"
+
+// Priorities are plain integers; these are conventions, not limits.
+const (
+	PriorityLow     = -10
+	PriorityDefault = 0
+	PriorityHigh    = 10
+)
+
+type EnqueueOptions struct {
+	// UniqueKey deduplicates active jobs: at most one queued or processing job may hold it.
+	UniqueKey  string
+	OnConflict ConflictAction
+	// Queue groups jobs so workers can choose what they consume.
+	Queue string
+	// Priority orders runnable jobs within a queue, highest first.
+	Priority int
+
+	// batchID is set by EnqueueBatch for member jobs.
+	batchID string
+}
+
+// WithUniqueKey makes the job unique among active jobs by key.
+func WithUniqueKey(key string, onConflict ConflictAction) func(*EnqueueOptions) {
+	return func(o *EnqueueOptions) {
+		o.UniqueKey = strings.TrimSpace(key)
+		o.OnConflict = onConflict
+	}
+}
+
+// WithQueue puts the job on a named queue instead of DefaultQueue.
+func WithQueue(name string) func(*EnqueueOptions) {
+	return func(o *EnqueueOptions) {
+		o.Queue = strings.TrimSpace(name)
+	}
+}
+
+// WithPriority runs the job ahead of lower-priority runnable jobs in its queue.
+func WithPriority(priority int) func(*EnqueueOptions) {
+	return func(o *EnqueueOptions) {
+		o.Priority = priority
+	}
+}
+
+func buildEnqueueOptions(opts []func(*EnqueueOptions)) EnqueueOptions {
+	options := EnqueueOptions{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&options)
+		}
+	}
+	if options.Queue == "" {
+		options.Queue = DefaultQueue
+	}
+	return options
+}
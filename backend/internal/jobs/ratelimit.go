@@ -0,0 +1,160 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimit allows at most Limit jobs of a type to start per Per, across all
+// workers sharing the same RateLimiter.
+type RateLimit struct {
+	Limit int
+	Per   time.Duration
+}
+
+// RateLimiter is a shared token bucket, e.g. cache.RateLimiter backed by Redis.
+type RateLimiter interface {
+	// Allow takes a token from key's bucket. When none is left it returns false
+	// and how long until one is.
+	Allow(ctx context.Context, key string, limit int, per time.Duration) (bool, time.Duration, error)
+}
+
+// WithRateLimit caps how many jobs of this type may start per interval, e.g.
+// WithRateLimit(2, time.Second) for a provider quota of two requests per second.
+func WithRateLimit(limit int, per time.Duration) func(*HandlerOptions) {
+	return func(o *HandlerOptions) {
+		o.RateLimit = &RateLimit{Limit: limit, Per: per}
+	}
+}
+
+// ParseRateLimits parses "name=N/duration" pairs separated by commas, e.g.
+// "send_email=10/1s,export=1/1m".
+func ParseRateLimits(raw string) (map[string]RateLimit, error) {
+	out := map[string]RateLimit{}
+	for _, pair := range strings.Split(raw, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		name, value, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid rate limit %q (expected name=N/duration)", pair)
+		}
+
+		count, per, ok := strings.Cut(strings.TrimSpace(value), "/")
+		limit, err := strconv.Atoi(strings.TrimSpace(count))
+		if !ok || err != nil || limit <= 0 {
+			return nil, fmt.Errorf("invalid rate limit %q (expected a positive count before /)", pair)
+		}
+		interval, err := time.ParseDuration(strings.TrimSpace(per))
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("invalid rate limit %q (expected a positive duration after /)", pair)
+		}
+		out[name] = RateLimit{Limit: limit, Per: interval}
+	}
+	return out, nil
+}
+
+// minThrottle is the shortest time a job type stays throttled locally, so a
+// limiter reporting a near-zero wait does not send the worker straight back to
+// Postgres and the limiter for the same type.
+const minThrottle = 100 * time.Millisecond
+
+// limiterErrorThrottle is how long a job type is held back after the limiter
+// fails, unless the claimer fails open.
+const limiterErrorThrottle = 5 * time.Second
+
+// allow reports whether a job of jobType may start now. When it may not, the
+// type is throttled locally until the limiter expects a token to be free.
+// Limiter errors throttle the type for limiterErrorThrottle, or allow the job
+// when the claimer is configured to fail open.
+func (c *Claimer) allow(ctx context.Context, jobType string) bool {
+	limit, ok := c.rateLimits[jobType]
+	if !ok || c.rateLimiter == nil || limit.Limit <= 0 || limit.Per <= 0 {
+		return true
+	}
+
+	allowed, wait, err := c.rateLimiter.Allow(ctx, "jobs:"+jobType, limit.Limit, limit.Per)
+	if err != nil {
+		if c.failOpen {
+			slog.Warn("job rate limiter unavailable; allowing job", "job_type", jobType, "error", err)
+			return true
+		}
+		slog.Warn("job rate limiter unavailable; throttling job type", "job_type", jobType, "error", err)
+		c.throttles.set(jobType, time.Now().Add(limiterErrorThrottle))
+		return false
+	}
+	if allowed {
+		return true
+	}
+
+	if wait <= 0 {
+		wait = limit.Per / time.Duration(limit.Limit)
+	}
+	if wait < minThrottle {
+		wait = minThrottle
+	}
+	c.throttles.set(jobType, time.Now().Add(wait))
+	return false
+}
+
+// ThrottledUntil returns the earliest time a currently throttled job type may
+// run again, or the zero time when nothing is throttled.
+func (c *Claimer) ThrottledUntil() time.Time {
+	return c.throttles.next(time.Now())
+}
+
+// throttles remembers which job types are over their rate limit and until when,
+// so claims skip them without asking the limiter again.
+type throttles struct {
+	mu    sync.Mutex
+	until map[string]time.Time
+}
+
+func newThrottles() *throttles {
+	return &throttles{until: map[string]time.Time{}}
+}
+
+func (t *throttles) set(jobType string, until time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.until[jobType] = until
+}
+
+// active returns the job types still throttled at now, dropping expired entries.
+func (t *throttles) active(now time.Time) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	out := []string{}
+	for jobType, until := range t.until {
+		if !now.Before(until) {
+			delete(t.until, jobType)
+			continue
+		}
+		out = append(out, jobType)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func (t *throttles) next(now time.Time) time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var earliest time.Time
+	for _, until := range t.until {
+		if now.Before(until) && (earliest.IsZero() || until.Before(earliest)) {
+			earliest = until
+		}
+	}
+	return earliest
+}
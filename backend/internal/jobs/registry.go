@@ -0,0 +1,179 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrUnknownJobType = errors.New("unknown job type")
+
+// HandlerOptions tune how jobs of a single type are executed.
+type HandlerOptions struct {
+	// Timeout bounds a single attempt. Zero means no timeout beyond the worker context.
+	Timeout time.Duration
+	// MaxAttempts overrides the max_attempts stored on the job row when positive.
+	MaxAttempts int
+	// Concurrency caps how many jobs of this type one worker runs at once. Zero means
+	// the type is only bounded by the worker's overall concurrency.
+	Concurrency int
+	// RetryPolicy replaces the default retry backoff when set.
+	RetryPolicy *RetryPolicy
+	// RateLimit caps how often jobs of this type start, across workers.
+	RateLimit *RateLimit
+}
+
+func WithTimeout(timeout time.Duration) func(*HandlerOptions) {
+	return func(o *HandlerOptions) {
+		o.Timeout = timeout
+	}
+}
+
+func WithMaxAttempts(maxAttempts int) func(*HandlerOptions) {
+	return func(o *HandlerOptions) {
+		o.MaxAttempts = maxAttempts
+	}
+}
+
+func WithConcurrency(limit int) func(*HandlerOptions) {
+	return func(o *HandlerOptions) {
+		o.Concurrency = limit
+	}
+}
+
+func WithRetryPolicy(policy RetryPolicy) func(*HandlerOptions) {
+	return func(o *HandlerOptions) {
+		o.RetryPolicy = &policy
+	}
+}
+
+type handler struct {
+	options HandlerOptions
+	run     func(ctx context.Context, payload []byte) error
+}
+
+// Registry maps job types to handlers. Packages register their own job types
+// at startup so the worker can dispatch without knowing about them.
+type Registry struct {
+	mu       sync.RWMutex
+	handlers map[string]handler
+}
+
+func NewRegistry() *Registry {
+	return &Registry{handlers: map[string]handler{}}
+}
+
+// Register adds a handler for jobType whose JSON payload decodes into T.
+// It panics on an empty or duplicate job type, since both are programming errors.
+func Register[T any](r *Registry, jobType string, fn func(ctx context.Context, payload T) error, opts ...func(*HandlerOptions)) {
+	jobType = strings.TrimSpace(jobType)
+	if jobType == "" {
+		panic("jobs: Register called with empty job type")
+	}
+	if fn == nil {
+		panic(fmt.Sprintf("jobs: Register called with nil handler for %q", jobType))
+	}
+
+	options := HandlerOptions{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&options)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.handlers[jobType]; exists {
+		panic(fmt.Sprintf("jobs: handler for %q already registered", jobType))
+	}
+
+	r.handlers[jobType] = handler{
+		options: options,
+		run: func(ctx context.Context, raw []byte) error {
+			var payload T
+			if err := json.Unmarshal(raw, &payload); err != nil {
+				// A payload that does not decode never will; do not retry it.
+				return Permanent(fmt.Errorf("decode payload: %w", err))
+			}
+			return fn(ctx, payload)
+		},
+	}
+}
+
+// Options returns the handler options for jobType, if it is registered.
+func (r *Registry) Options(jobType string) (HandlerOptions, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	h, ok := r.handlers[jobType]
+	return h.options, ok
+}
+
+// Types returns the registered job types in sorted order.
+func (r *Registry) Types() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]string, 0, len(r.handlers))
+	for jobType := range r.handlers {
+		out = append(out, jobType)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Run executes job with its registered handler, applying the handler timeout.
+func (r *Registry) Run(ctx context.Context, job *Job) error {
+	r.mu.RLock()
+	h, ok := r.handlers[job.Type]
+	r.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w %q", ErrUnknownJobType, job.Type)
+	}
+
+	if h.options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.options.Timeout)
+		defer cancel()
+	}
+
+	return h.run(ctx, job.PayloadJSON)
+}
+
+// MaxAttempts returns the attempt limit for job, preferring the handler override.
+func (r *Registry) MaxAttempts(job *Job) int {
+	if options, ok := r.Options(job.Type); ok && options.MaxAttempts > 0 {
+		return options.MaxAttempts
+	}
+	return job.MaxAttempts
+}
+
+// RetryDelay returns how long job waits before its next attempt under the
+// handler's retry policy, or zero to use the default backoff.
+func (r *Registry) RetryDelay(job *Job) time.Duration {
+	if options, ok := r.Options(job.Type); ok && options.RetryPolicy != nil {
+		return options.RetryPolicy.Delay(job.Attempts)
+	}
+	return 0
+}
+
+// RateLimits returns the rate limit of every registered type that has one,
+// for ClaimerConfig.RateLimits.
+func (r *Registry) RateLimits() map[string]RateLimit {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := map[string]RateLimit{}
+	for jobType, h := range r.handlers {
+		if h.options.RateLimit != nil {
+			out[jobType] = *h.options.RateLimit
+		}
+	}
+	return out
+}
@@ -0,0 +1,86 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type testPayload struct {
+	Name string `json:"name"`
+}
+
+func TestRegistryRun(t *testing.T) {
+	t.Run("decodes payload into the registered type", func(t *testing.T) {
+		registry := NewRegistry()
+		var got string
+		Register(registry, "greet", func(_ context.Context, p testPayload) error {
+			got = p.Name
+			return nil
+		})
+
+		if err := registry.Run(context.Background(), &Job{Type: "greet", PayloadJSON: []byte(`{"name":"ada"}`)}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != "ada" {
+			t.Fatalf("expected ada, got %q", got)
+		}
+	})
+
+	t.Run("rejects unknown job types", func(t *testing.T) {
+		registry := NewRegistry()
+		err := registry.Run(context.Background(), &Job{Type: "missing"})
+		if !errors.Is(err, ErrUnknownJobType) {
+			t.Fatalf("expected ErrUnknownJobType, got %v", err)
+		}
+	})
+
+	t.Run("reports payload decode errors", func(t *testing.T) {
+		registry := NewRegistry()
+		Register(registry, "greet", func(context.Context, testPayload) error { return nil })
+
+		if err := registry.Run(context.Background(), &Job{Type: "greet", PayloadJSON: []byte(`not json`)}); err == nil {
+			t.Fatalf("expected decode error")
+		}
+	})
+
+	t.Run("applies handler timeout", func(t *testing.T) {
+		registry := NewRegistry()
+		Register(registry, "slow", func(ctx context.Context, _ testPayload) error {
+			if _, ok := ctx.Deadline(); !ok {
+				t.Fatalf("expected deadline on handler context")
+			}
+			return nil
+		}, WithTimeout(time.Second))
+
+		if err := registry.Run(context.Background(), &Job{Type: "slow", PayloadJSON: []byte(`{}`)}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestRegistryMaxAttempts(t *testing.T) {
+	registry := NewRegistry()
+	Register(registry, "limited", func(context.Context, testPayload) error { return nil }, WithMaxAttempts(3))
+	Register(registry, "default", func(context.Context, testPayload) error { return nil })
+
+	if got := registry.MaxAttempts(&Job{Type: "limited", MaxAttempts: 10}); got != 3 {
+		t.Fatalf("expected handler override 3, got %d", got)
+	}
+	if got := registry.MaxAttempts(&Job{Type: "default", MaxAttempts: 10}); got != 10 {
+		t.Fatalf("expected job max attempts 10, got %d", got)
+	}
+}
+
+func TestRegisterDuplicatePanics(t *testing.T) {
+	registry := NewRegistry()
+	Register(registry, "dup", func(context.Context, testPayload) error { return nil })
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected panic on duplicate registration")
+		}
+	}()
+	Register(registry, "dup", func(context.Context, testPayload) error { return nil })
+}
@@ -0,0 +1,203 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const PruneJobType = "jobs_prune"
+
+// PruneJob is the payload of a jobs_prune job. A retention of zero days keeps
+// jobs in that status forever.
+type PruneJob struct {
+	DoneRetentionDays   int  `json:"doneRetentionDays"`
+	FailedRetentionDays int  `json:"failedRetentionDays"`
+	Archive             bool `json:"archive,omitempty"`
+}
+
+// RetentionPolicy says how long finished jobs stay in the jobs table.
+type RetentionPolicy struct {
+	// DoneAfter applies to done and cancelled jobs and finished batches; zero keeps them.
+	DoneAfter time.Duration
+	// FailedAfter applies to failed jobs; zero keeps them.
+	FailedAfter time.Duration
+	// Archive moves expired jobs (with their attempt history) to jobs_archive
+	// instead of deleting them.
+	Archive bool
+}
+
+// PruneResult counts the jobs removed from the jobs table per status.
+type PruneResult struct {
+	Done      int64
+	Failed    int64
+	Cancelled int64
+	// Batches counts finished job batches removed under DoneAfter.
+	Batches int64
+}
+
+func (r PruneResult) Total() int64 {
+	return r.Done + r.Failed + r.Cancelled
+}
+
+const pruneBatchSize = 1000
+
+// Prune deletes or archives finished jobs older than the policy allows, in
+// batches so a large backlog never holds long locks on the jobs table.
+func (s *Store) Prune(ctx context.Context, policy RetentionPolicy) (PruneResult, error) {
+	var result PruneResult
+	now := time.Now().UTC()
+
+	targets := []struct {
+		status string
+		after  time.Duration
+		count  *int64
+	}{
+		{StatusDone, policy.DoneAfter, &result.Done},
+		{StatusCancelled, policy.DoneAfter, &result.Cancelled},
+		{StatusFailed, policy.FailedAfter, &result.Failed},
+	}
+
+	for _, target := range targets {
+		if target.after <= 0 {
+			continue
+		}
+		cutoff := now.Add(-target.after)
+
+		for {
+			n, err := s.pruneBatch(ctx, target.status, cutoff, policy.Archive)
+			if err != nil {
+				return result, err
+			}
+			*target.count += n
+			if n < pruneBatchSize {
+				break
+			}
+		}
+	}
+	if policy.DoneAfter > 0 {
+		ct, err := s.db.Exec(ctx, `
+			DELETE FROM job_batches
+			WHERE finished_at < $1
+		`, now.Add(-policy.DoneAfter))
+		if err != nil {
+			return result, fmt.Errorf("prune job batches: %w", err)
+		}
+		result.Batches = ct.RowsAffected()
+	}
+	return result, nil
+}
+
+func (s *Store) pruneBatch(ctx context.Context, status string, cutoff time.Time, archive bool) (int64, error) {
+	if !archive {
+		ct, err := s.db.Exec(ctx, `
+			DELETE FROM jobs
+			WHERE id IN (
+				SELECT id
+				FROM jobs
+				WHERE status = $1
+				  AND updated_at < $2
+				ORDER BY updated_at ASC
+				LIMIT $3
+				FOR UPDATE SKIP LOCKED
+			)
+		`, status, cutoff, pruneBatchSize)
+		if err != nil {
+			return 0, fmt.Errorf("prune %s jobs: %w", status, err)
+		}
+		return ct.RowsAffected(), nil
+	}
+
+	// Attempts are read from the statement's snapshot, before the cascade removes them.
+	// A job already in the archive (e.g. one restored and finished again) is
+	// overwritten, so every deleted job ends up archived. The count is of deleted
+	// jobs, which is what tells Prune whether more remain.
+	var n int64
+	err := s.db.QueryRow(ctx, `
+		WITH moved AS (
+			DELETE FROM jobs
+			WHERE id IN (
+				SELECT id
+				FROM jobs
+				WHERE status = $1
+				  AND updated_at < $2
+				ORDER BY updated_at ASC
+				LIMIT $3
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING id, type, queue, priority, payload, metadata, status, run_at, attempts, max_attempts,
+			          last_error, unique_key, batch_id, created_at, updated_at
+		), archived AS (
+			INSERT INTO jobs_archive (
+				id, type, queue, priority, payload, metadata, status, run_at, attempts, max_attempts,
+				last_error, unique_key, batch_id, attempt_history, created_at, updated_at
+			)
+			SELECT
+				m.id, m.type, m.queue, m.priority, m.payload, m.metadata, m.status, m.run_at, m.attempts, m.max_attempts,
+				m.last_error, m.unique_key, m.batch_id,
+				COALESCE((
+					SELECT jsonb_agg(jsonb_build_object(
+						'attempt', a.attempt,
+						'workerId', a.worker_id,
+						'startedAt', a.started_at,
+						'finishedAt', a.finished_at,
+						'durationMs', a.duration_ms,
+						'outcome', a.outcome,
+						'error', a.error
+					) ORDER BY a.started_at)
+					FROM job_attempts a
+					WHERE a.job_id = m.id
+				), '[]'::jsonb),
+				m.created_at, m.updated_at
+			FROM moved m
+			ON CONFLICT (id) DO UPDATE
+			SET type = EXCLUDED.type,
+			    queue = EXCLUDED.queue,
+			    priority = EXCLUDED.priority,
+			    payload = EXCLUDED.payload,
+			    metadata = EXCLUDED.metadata,
+			    status = EXCLUDED.status,
+			    run_at = EXCLUDED.run_at,
+			    attempts = EXCLUDED.attempts,
+			    max_attempts = EXCLUDED.max_attempts,
+			    last_error = EXCLUDED.last_error,
+			    unique_key = EXCLUDED.unique_key,
+			    batch_id = EXCLUDED.batch_id,
+			    attempt_history = EXCLUDED.attempt_history,
+			    created_at = EXCLUDED.created_at,
+			    updated_at = EXCLUDED.updated_at,
+			    archived_at = now()
+		)
+		SELECT count(*) FROM moved
+	`, status, cutoff, pruneBatchSize).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("archive %s jobs: %w", status, err)
+	}
+	return n, nil
+}
+
+// RegisterPruneJob registers the jobs_prune handler, which applies the retention
+// policy carried in its payload.
+func RegisterPruneJob(registry *Registry, store *Store) {
+	Register(registry, PruneJobType, func(ctx context.Context, job PruneJob) error {
+		result, err := store.Prune(ctx, RetentionPolicy{
+			DoneAfter:   time.Duration(job.DoneRetentionDays) * 24 * time.Hour,
+			FailedAfter: time.Duration(job.FailedRetentionDays) * 24 * time.Hour,
+			Archive:     job.Archive,
+		})
+		if err != nil {
+			return err
+		}
+		if result.Total() > 0 || result.Batches > 0 {
+			slog.Info("pruned finished jobs",
+				"done", result.Done,
+				"failed", result.Failed,
+				"cancelled", result.Cancelled,
+				"batches", result.Batches,
+				"archived", job.Archive,
+			)
+		}
+		return nil
+	}, WithTimeout(10*time.Minute), WithConcurrency(1))
+}
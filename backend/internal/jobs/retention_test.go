@@ -0,0 +1,75 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"saas-core-template/backend/internal/testdb"
+)
+
+func TestPruneArchive(t *testing.T) {
+	ctx := context.Background()
+	pool := testdb.New(t)
+	store := NewStore(pool)
+
+	finished := func(status string, age time.Duration) string {
+		t.Helper()
+		id, err := store.Enqueue(ctx, "export", map[string]string{"status": status}, time.Now().UTC())
+		if err != nil {
+			t.Fatalf("enqueue: %v", err)
+		}
+		if _, err := pool.Exec(ctx, `
+			UPDATE jobs SET status = $2, updated_at = now() - ($3::int * interval '1 second') WHERE id = $1::uuid
+		`, id, status, int(age.Seconds())); err != nil {
+			t.Fatalf("finish job: %v", err)
+		}
+		return id
+	}
+	count := func(table string, id string) int {
+		t.Helper()
+		var n int
+		if err := pool.QueryRow(ctx, `SELECT count(*) FROM `+table+` WHERE id = $1::uuid`, id).Scan(&n); err != nil {
+			t.Fatalf("count %s: %v", table, err)
+		}
+		return n
+	}
+
+	oldDone := finished(StatusDone, 48*time.Hour)
+	recentDone := finished(StatusDone, time.Hour)
+	oldFailed := finished(StatusFailed, 48*time.Hour)
+
+	// A copy left behind by an earlier run must not block archiving the job again.
+	if _, err := pool.Exec(ctx, `
+		INSERT INTO jobs_archive (id, type, queue, priority, payload, status, run_at, attempts, max_attempts, created_at, updated_at)
+		VALUES ($1::uuid, 'stale', 'default', 0, '{}', 'queued', now(), 0, 1, now(), now())
+	`, oldDone); err != nil {
+		t.Fatalf("insert stale archive row: %v", err)
+	}
+
+	result, err := store.Prune(ctx, RetentionPolicy{DoneAfter: 24 * time.Hour, Archive: true})
+	if err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+	if result.Done != 1 || result.Failed != 0 || result.Cancelled != 0 {
+		t.Fatalf("expected one done job pruned, got %+v", result)
+	}
+
+	if count("jobs", oldDone) != 0 || count("jobs_archive", oldDone) != 1 {
+		t.Fatalf("expected the old done job to move to jobs_archive")
+	}
+	var archivedType, archivedStatus string
+	if err := pool.QueryRow(ctx, `SELECT type, status FROM jobs_archive WHERE id = $1::uuid`, oldDone).Scan(&archivedType, &archivedStatus); err != nil {
+		t.Fatalf("load archived job: %v", err)
+	}
+	if archivedType != "export" || archivedStatus != StatusDone {
+		t.Fatalf("expected the archive row to be replaced, got %s/%s", archivedType, archivedStatus)
+	}
+
+	if count("jobs", recentDone) != 1 {
+		t.Fatalf("expected a recent done job to be kept")
+	}
+	if count("jobs", oldFailed) != 1 {
+		t.Fatalf("expected failed jobs to be kept without a failed retention")
+	}
+}
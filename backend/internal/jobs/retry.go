@@ -0,0 +1,107 @@
+package jobs
+
+import (
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+// BackoffStrategy shapes how the delay grows between retries.
+type BackoffStrategy int
+
+const (
+	// BackoffExponential doubles the delay after every attempt: base, 2*base, 4*base, ...
+	BackoffExponential BackoffStrategy = iota
+	// BackoffLinear grows the delay by base after every attempt: base, 2*base, 3*base, ...
+	BackoffLinear
+	// BackoffFixed waits base between every attempt.
+	BackoffFixed
+)
+
+// RetryPolicy decides how long a failed job waits before its next attempt.
+type RetryPolicy struct {
+	Strategy BackoffStrategy
+	// BaseDelay is the delay before the first retry. Defaults to 5s.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay, jitter included. Defaults to 10m.
+	MaxDelay time.Duration
+	// Jitter spreads each delay uniformly by up to this fraction in either
+	// direction (0.2 means ±20%), so jobs that failed together do not retry together.
+	Jitter float64
+}
+
+// Delay returns the wait before the retry that follows the given 1-based attempt.
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	return p.delay(attempt, rand.Float64)
+}
+
+// delay is Delay with an injectable source of uniform floats in [0, 1).
+func (p RetryPolicy) delay(attempt int, random func() float64) time.Duration {
+	base := p.BaseDelay
+	if base <= 0 {
+		base = 5 * time.Second
+	}
+	maxDelay := p.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = 10 * time.Minute
+	}
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	var delay time.Duration
+	switch p.Strategy {
+	case BackoffLinear:
+		delay = base * time.Duration(attempt)
+	case BackoffFixed:
+		delay = base
+	default:
+		delay = base
+		for i := 1; i < attempt && delay < maxDelay; i++ {
+			delay *= 2
+		}
+	}
+	if delay > maxDelay || delay <= 0 {
+		delay = maxDelay
+	}
+
+	if p.Jitter > 0 {
+		jitter := p.Jitter
+		if jitter > 1 {
+			jitter = 1
+		}
+		delay += time.Duration((random()*2 - 1) * jitter * float64(delay))
+		if delay > maxDelay {
+			delay = maxDelay
+		}
+	}
+	return delay
+}
+
+// PermanentError marks a handler error that retrying cannot fix, such as a
+// rejected email address. The job fails immediately regardless of attempts left.
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return "permanent: " + e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// Permanent wraps err so the job is not retried. Permanent(nil) returns nil.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &PermanentError{Err: err}
+}
+
+// IsPermanent reports whether err, or any error it wraps, is a PermanentError.
+func IsPermanent(err error) bool {
+	var permanent *PermanentError
+	return errors.As(err, &permanent)
+}
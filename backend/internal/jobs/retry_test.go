@@ -0,0 +1,70 @@
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	noJitter := func() float64 { return 0.5 }
+
+	cases := []struct {
+		name    string
+		policy  RetryPolicy
+		attempt int
+		want    time.Duration
+	}{
+		{"exponential first retry", RetryPolicy{BaseDelay: time.Second}, 1, time.Second},
+		{"exponential doubles", RetryPolicy{BaseDelay: time.Second}, 4, 8 * time.Second},
+		{"exponential capped", RetryPolicy{BaseDelay: time.Second, MaxDelay: time.Minute}, 40, time.Minute},
+		{"linear", RetryPolicy{Strategy: BackoffLinear, BaseDelay: 10 * time.Second}, 3, 30 * time.Second},
+		{"linear capped", RetryPolicy{Strategy: BackoffLinear, BaseDelay: time.Minute, MaxDelay: 2 * time.Minute}, 5, 2 * time.Minute},
+		{"fixed", RetryPolicy{Strategy: BackoffFixed, BaseDelay: 30 * time.Second}, 7, 30 * time.Second},
+		{"defaults", RetryPolicy{}, 1, 5 * time.Second},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.policy.delay(tc.attempt, noJitter); got != tc.want {
+				t.Fatalf("expected %s, got %s", tc.want, got)
+			}
+		})
+	}
+
+	t.Run("jitter stays within bounds", func(t *testing.T) {
+		policy := RetryPolicy{Strategy: BackoffFixed, BaseDelay: 10 * time.Second, Jitter: 0.2}
+		if got := policy.delay(1, func() float64 { return 0 }); got != 8*time.Second {
+			t.Fatalf("expected 8s at the low end, got %s", got)
+		}
+		if got := policy.delay(1, func() float64 { return 0.999999 }); got < 11*time.Second || got > 12*time.Second {
+			t.Fatalf("expected just under 12s at the high end, got %s", got)
+		}
+	})
+
+	t.Run("jitter never exceeds max delay", func(t *testing.T) {
+		policy := RetryPolicy{Strategy: BackoffFixed, BaseDelay: time.Minute, MaxDelay: time.Minute, Jitter: 0.5}
+		if got := policy.delay(1, func() float64 { return 0.999999 }); got != time.Minute {
+			t.Fatalf("expected 1m, got %s", got)
+		}
+	})
+}
+
+func TestPermanent(t *testing.T) {
+	if Permanent(nil) != nil {
+		t.Fatalf("expected Permanent(nil) to be nil")
+	}
+
+	base := errors.New("invalid recipient")
+	err := fmt.Errorf("send email: %w", Permanent(base))
+	if !IsPermanent(err) {
+		t.Fatalf("expected wrapped permanent error to be detected")
+	}
+	if !errors.Is(err, base) {
+		t.Fatalf("expected permanent error to unwrap to its cause")
+	}
+	if IsPermanent(base) {
+		t.Fatalf("expected plain error not to be permanent")
+	}
+}
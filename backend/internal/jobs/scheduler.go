@@ -0,0 +1,210 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type SchedulerConfig struct {
+	// TickInterval is how often due schedules are checked. Defaults to 15s.
+	TickInterval time.Duration
+	OnError      func(err error)
+}
+
+// Scheduler enqueues jobs for registered recurring schedules. Every replica may
+// run one; each schedule's row in job_schedules is locked while it fires, so a
+// given run is enqueued by exactly one of them.
+type Scheduler struct {
+	db       *pgxpool.Pool
+	enqueuer TxEnqueuer
+	cfg      SchedulerConfig
+	entries  map[string]scheduleEntry
+}
+
+type scheduleEntry struct {
+	name     string
+	spec     string
+	schedule Schedule
+	jobType  string
+	payload  any
+}
+
+func NewScheduler(db *pgxpool.Pool, enqueuer TxEnqueuer, cfg SchedulerConfig) *Scheduler {
+	if cfg.TickInterval <= 0 {
+		cfg.TickInterval = 15 * time.Second
+	}
+	if cfg.OnError == nil {
+		cfg.OnError = func(err error) { slog.Error("scheduler error", "error", err) }
+	}
+
+	return &Scheduler{
+		db:       db,
+		enqueuer: enqueuer,
+		cfg:      cfg,
+		entries:  map[string]scheduleEntry{},
+	}
+}
+
+// Add registers a schedule that enqueues jobType with payload. name identifies the
+// schedule across deploys; changing its spec resets the next run time.
+func (s *Scheduler) Add(name string, spec string, jobType string, payload any) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("missing schedule name")
+	}
+	if _, exists := s.entries[name]; exists {
+		return fmt.Errorf("schedule %q already registered", name)
+	}
+
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		return fmt.Errorf("schedule %q: %w", name, err)
+	}
+
+	s.entries[name] = scheduleEntry{
+		name:     name,
+		spec:     strings.TrimSpace(spec),
+		schedule: schedule,
+		jobType:  jobType,
+		payload:  payload,
+	}
+	return nil
+}
+
+// Run fires due schedules until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context) {
+	if len(s.entries) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.cfg.TickInterval)
+	defer ticker.Stop()
+
+	// sync is retried every tick until it succeeds, so a database that is
+	// unreachable at startup does not leave schedules without rows.
+	synced := false
+	for {
+		if !synced {
+			if err := s.sync(ctx); err != nil {
+				if !errors.Is(err, context.Canceled) {
+					s.cfg.OnError(err)
+				}
+			} else {
+				synced = true
+			}
+		}
+
+		if err := s.tick(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			s.cfg.OnError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sync upserts a row per registered schedule, keeping next_run_at unless the spec changed.
+func (s *Scheduler) sync(ctx context.Context) error {
+	now := time.Now().UTC()
+	for _, entry := range s.entries {
+		if _, err := s.db.Exec(ctx, `
+			INSERT INTO job_schedules (name, spec, job_type, next_run_at)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (name) DO UPDATE
+			SET next_run_at = CASE
+			        WHEN job_schedules.spec <> EXCLUDED.spec THEN EXCLUDED.next_run_at
+			        ELSE job_schedules.next_run_at
+			    END,
+			    spec = EXCLUDED.spec,
+			    job_type = EXCLUDED.job_type,
+			    updated_at = now()
+		`, entry.name, entry.spec, entry.jobType, entry.schedule.Next(now)); err != nil {
+			return fmt.Errorf("sync schedule %q: %w", entry.name, err)
+		}
+	}
+	return nil
+}
+
+func (s *Scheduler) tick(ctx context.Context) error {
+	names := make([]string, 0, len(s.entries))
+	for name := range s.entries {
+		names = append(names, name)
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin schedule tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+		SELECT name
+		FROM job_schedules
+		WHERE name = ANY($1::text[])
+		  AND next_run_at <= now()
+		FOR UPDATE SKIP LOCKED
+	`, names)
+	if err != nil {
+		return fmt.Errorf("select due schedules: %w", err)
+	}
+
+	var due []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan due schedule: %w", err)
+		}
+		due = append(due, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("select due schedules rows: %w", err)
+	}
+	if len(due) == 0 {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	for _, name := range due {
+		entry := s.entries[name]
+
+		// Skip a run while the previous one is still queued or processing, so a slow
+		// job does not pile up copies of itself.
+		jobID, err := s.enqueuer.EnqueueTx(ctx, tx, entry.jobType, entry.payload, now, WithUniqueKey("schedule:"+entry.name, OnConflictSkip))
+		if err != nil {
+			return fmt.Errorf("enqueue scheduled job %q: %w", entry.name, err)
+		}
+
+		if _, err := tx.Exec(ctx, `
+			UPDATE job_schedules
+			SET last_run_at = $2,
+			    next_run_at = $3,
+			    last_job_id = COALESCE(NULLIF($4, '')::uuid, last_job_id),
+			    updated_at = now()
+			WHERE name = $1
+		`, entry.name, now, entry.schedule.Next(now), jobID); err != nil {
+			return fmt.Errorf("advance schedule %q: %w", entry.name, err)
+		}
+
+		if jobID == "" {
+			slog.Info("scheduled run skipped; previous job still active", "schedule", entry.name, "job_type", entry.jobType)
+			continue
+		}
+		slog.Info("scheduled job enqueued", "schedule", entry.name, "job_type", entry.jobType, "job_id", jobID)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit schedule tx: %w", err)
+	}
+	return nil
+}
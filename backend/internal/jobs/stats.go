@@ -0,0 +1,69 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// TypeStats summarizes the jobs of one type that need attention.
+type TypeStats struct {
+	Type       string
+	Queued     int64
+	Processing int64
+	Failed     int64
+	// OldestQueuedAge is how long the oldest runnable queued job has been
+	// waiting past its run_at. Jobs scheduled in the future do not count.
+	OldestQueuedAge time.Duration
+}
+
+// Stats is a point-in-time view of the queue backlog.
+type Stats struct {
+	Types           []TypeStats
+	Queued          int64
+	Processing      int64
+	Failed          int64
+	OldestQueuedAge time.Duration
+}
+
+// Stats counts queued, processing and failed jobs per type.
+func (s *Store) Stats(ctx context.Context) (Stats, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT
+			type,
+			count(*) FILTER (WHERE status = 'queued'),
+			count(*) FILTER (WHERE status = 'processing'),
+			count(*) FILTER (WHERE status = 'failed'),
+			COALESCE(EXTRACT(EPOCH FROM now() - min(run_at) FILTER (WHERE status = 'queued' AND run_at <= now())), 0)::float8
+		FROM jobs
+		WHERE status IN ('queued', 'processing', 'failed')
+		GROUP BY type
+		ORDER BY type
+	`)
+	if err != nil {
+		return Stats{}, fmt.Errorf("query job stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := Stats{Types: []TypeStats{}}
+	for rows.Next() {
+		var ts TypeStats
+		var oldestSeconds float64
+		if err := rows.Scan(&ts.Type, &ts.Queued, &ts.Processing, &ts.Failed, &oldestSeconds); err != nil {
+			return Stats{}, fmt.Errorf("scan job stats: %w", err)
+		}
+		ts.OldestQueuedAge = time.Duration(oldestSeconds * float64(time.Second))
+
+		stats.Types = append(stats.Types, ts)
+		stats.Queued += ts.Queued
+		stats.Processing += ts.Processing
+		stats.Failed += ts.Failed
+		if ts.OldestQueuedAge > stats.OldestQueuedAge {
+			stats.OldestQueuedAge = ts.OldestQueuedAge
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return Stats{}, fmt.Errorf("query job stats rows: %w", err)
+	}
+	return stats, nil
+}
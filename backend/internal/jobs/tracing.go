@@ -0,0 +1,38 @@
+package jobs
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "saas-core-template/backend/internal/jobs"
+
+// jobMetadata captures the trace context of ctx (the W3C traceparent and
+// tracestate with the default propagator) so the job's execution can be
+// joined to the request that enqueued it.
+func jobMetadata(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	return carrier
+}
+
+// startJobSpan starts the span covering one attempt of job, as a child of the
+// trace context captured at enqueue time when there is one.
+func startJobSpan(ctx context.Context, job *Job) (context.Context, trace.Span) {
+	ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(job.Metadata))
+
+	return otel.Tracer(tracerName).Start(ctx, "job "+job.Type,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("job.id", job.ID),
+			attribute.String("job.type", job.Type),
+			attribute.String("job.queue", job.Queue),
+			attribute.Int("job.attempt", job.Attempts),
+			attribute.Int("job.priority", job.Priority),
+		),
+	)
+}
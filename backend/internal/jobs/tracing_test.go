@@ -0,0 +1,35 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTraceContextRoundTrip(t *testing.T) {
+	previous := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(previous) })
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	parent := trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID, TraceFlags: trace.FlagsSampled})
+
+	metadata := jobMetadata(trace.ContextWithSpanContext(context.Background(), parent))
+	if got := metadata["traceparent"]; got != "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01" {
+		t.Fatalf("unexpected traceparent %q", got)
+	}
+
+	ctx, span := startJobSpan(context.Background(), &Job{ID: "job-1", Type: "send_email", Metadata: metadata})
+	defer span.End()
+	if got := trace.SpanContextFromContext(ctx).TraceID(); got != traceID {
+		t.Fatalf("expected job span in trace %s, got %s", traceID, got)
+	}
+
+	if len(jobMetadata(context.Background())) != 0 {
+		t.Fatalf("expected no metadata without a span")
+	}
+}
@@ -0,0 +1,74 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"saas-core-template/backend/internal/testdb"
+)
+
+func TestUniqueKeyConflicts(t *testing.T) {
+	ctx := context.Background()
+	pool := testdb.New(t)
+	store := NewStore(pool)
+	runAt := time.Now().UTC()
+
+	enqueue := func(n int, onConflict ConflictAction) string {
+		t.Helper()
+		id, err := store.Enqueue(ctx, "sync", map[string]int{"n": n}, runAt, WithUniqueKey("sync:1", onConflict))
+		if err != nil {
+			t.Fatalf("enqueue: %v", err)
+		}
+		return id
+	}
+	payloadOf := func(id string) int {
+		t.Helper()
+		var n int
+		if err := pool.QueryRow(ctx, `SELECT (payload->>'n')::int FROM jobs WHERE id = $1::uuid`, id).Scan(&n); err != nil {
+			t.Fatalf("load payload: %v", err)
+		}
+		return n
+	}
+	setStatus := func(id string, status string) {
+		t.Helper()
+		if _, err := pool.Exec(ctx, `UPDATE jobs SET status = $2 WHERE id = $1::uuid`, id, status); err != nil {
+			t.Fatalf("set status: %v", err)
+		}
+	}
+
+	first := enqueue(1, OnConflictSkip)
+	if first == "" {
+		t.Fatalf("expected the first job to be inserted")
+	}
+	if id := enqueue(2, OnConflictSkip); id != "" {
+		t.Fatalf("expected skip to return no ID, got %q", id)
+	}
+	if id := enqueue(3, OnConflictReturnExisting); id != first {
+		t.Fatalf("expected the existing ID %s, got %q", first, id)
+	}
+	if n := payloadOf(first); n != 1 {
+		t.Fatalf("expected skip and return-existing to keep the payload, got %d", n)
+	}
+
+	if id := enqueue(4, OnConflictReplace); id != first {
+		t.Fatalf("expected replace to return %s, got %q", first, id)
+	}
+	if n := payloadOf(first); n != 4 {
+		t.Fatalf("expected replace to overwrite the queued payload, got %d", n)
+	}
+
+	setStatus(first, "processing")
+	if id := enqueue(5, OnConflictReplace); id != first {
+		t.Fatalf("expected replace of a processing job to return %s, got %q", first, id)
+	}
+	if n := payloadOf(first); n != 4 {
+		t.Fatalf("expected a processing job to keep its payload, got %d", n)
+	}
+
+	setStatus(first, "done")
+	second := enqueue(6, OnConflictSkip)
+	if second == "" || second == first {
+		t.Fatalf("expected a finished job to free the key, got %q", second)
+	}
+}
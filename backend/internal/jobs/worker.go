@@ -0,0 +1,436 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+type WorkerConfig struct {
+	// Concurrency is the number of jobs run in parallel by this process.
+	Concurrency int
+	// TypeConcurrency caps parallelism per job type, overriding the registry's WithConcurrency.
+	TypeConcurrency map[string]int
+	// Queues restricts the worker to the named queues. Each claim tries them in
+	// a random order weighted by the values, so a queue with weight 6 is tried
+	// first six times as often as one with weight 1. Empty means every queue.
+	Queues       map[string]int
+	PollInterval time.Duration
+	ReapInterval time.Duration
+	// HeartbeatInterval is how often running jobs have their lock extended.
+	// Defaults to a third of the claimer's LockTTL.
+	HeartbeatInterval time.Duration
+	// ShutdownTimeout is how long in-flight jobs may keep running after Run's
+	// context is cancelled before their own contexts are cancelled too.
+	ShutdownTimeout time.Duration
+	// OnError receives claim and bookkeeping errors; handler errors are recorded on the job instead.
+	OnError func(err error)
+}
+
+// Worker claims jobs and runs them through a Registry on a bounded pool of goroutines.
+type Worker struct {
+	claimer  *Claimer
+	registry *Registry
+	cfg      WorkerConfig
+	wake     chan struct{}
+
+	mu    sync.Mutex
+	slots *slots
+
+	// lastPoll is the unix nano time of the last claim query that succeeded.
+	lastPoll atomic.Int64
+
+	throttleTimer *time.Timer
+}
+
+func NewWorker(claimer *Claimer, registry *Registry, cfg WorkerConfig) *Worker {
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 1
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+	if cfg.ReapInterval <= 0 {
+		cfg.ReapInterval = 30 * time.Second
+	}
+	if cfg.ShutdownTimeout <= 0 {
+		cfg.ShutdownTimeout = 30 * time.Second
+	}
+	if cfg.HeartbeatInterval <= 0 {
+		cfg.HeartbeatInterval = claimer.lockTTL / 3
+	}
+	if cfg.OnError == nil {
+		cfg.OnError = func(err error) { slog.Error("worker error", "error", err) }
+	}
+
+	limits := map[string]int{}
+	for _, jobType := range registry.Types() {
+		if options, _ := registry.Options(jobType); options.Concurrency > 0 {
+			limits[jobType] = options.Concurrency
+		}
+	}
+	for jobType, limit := range cfg.TypeConcurrency {
+		limits[jobType] = limit
+	}
+
+	return &Worker{
+		claimer:  claimer,
+		registry: registry,
+		cfg:      cfg,
+		wake:     make(chan struct{}, 1),
+		slots:    newSlots(cfg.Concurrency, limits),
+	}
+}
+
+// Wake asks the worker to look for jobs now instead of waiting for the next poll.
+func (w *Worker) Wake() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// LastPoll returns when the worker last queried for jobs successfully, or the
+// zero time if it has not yet.
+func (w *Worker) LastPoll() time.Time {
+	nanos := w.lastPoll.Load()
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
+
+// Running returns the number of jobs currently executing.
+func (w *Worker) Running() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.slots.running
+}
+
+// Run claims and executes jobs until ctx is cancelled, then waits for in-flight
+// jobs to finish (up to ShutdownTimeout) before returning.
+func (w *Worker) Run(ctx context.Context) {
+	// Handlers run on a context detached from ctx so a shutdown signal stops
+	// claiming without interrupting jobs that are already running.
+	jobCtx, cancelJobs := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelJobs()
+
+	var wg sync.WaitGroup
+
+	pollTicker := time.NewTicker(w.cfg.PollInterval)
+	defer pollTicker.Stop()
+
+	reapTicker := time.NewTicker(w.cfg.ReapInterval)
+	defer reapTicker.Stop()
+
+	for {
+		w.fill(ctx, jobCtx, &wg)
+
+		select {
+		case <-ctx.Done():
+			w.drain(&wg, cancelJobs)
+			return
+		case <-pollTicker.C:
+		case <-w.wake:
+		case <-reapTicker.C:
+			w.reap(ctx)
+		}
+	}
+}
+
+// fill claims jobs until the pool is full or nothing runnable is left.
+func (w *Worker) fill(ctx context.Context, jobCtx context.Context, wg *sync.WaitGroup) {
+	for ctx.Err() == nil {
+		w.mu.Lock()
+		full := w.slots.full()
+		exclude := w.slots.saturated()
+		w.mu.Unlock()
+		if full {
+			// A full pool is busy, not stuck; keep readiness fresh.
+			w.lastPoll.Store(time.Now().UnixNano())
+			return
+		}
+
+		job, err := w.claim(ctx, exclude)
+		if err != nil {
+			if !errors.Is(err, context.Canceled) {
+				w.cfg.OnError(err)
+			}
+			return
+		}
+		w.lastPoll.Store(time.Now().UnixNano())
+		if job == nil {
+			w.wakeAfterThrottle()
+			return
+		}
+
+		w.mu.Lock()
+		w.slots.acquire(job.Type)
+		w.mu.Unlock()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.execute(jobCtx, job)
+
+			w.mu.Lock()
+			w.slots.release(job.Type)
+			w.mu.Unlock()
+			w.Wake()
+		}()
+	}
+}
+
+// wakeAfterThrottle schedules a wake for when the earliest rate-limited job
+// type may run again, so throttled backlogs drain without waiting for a poll.
+func (w *Worker) wakeAfterThrottle() {
+	until := w.claimer.ThrottledUntil()
+	if until.IsZero() {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.throttleTimer == nil {
+		w.throttleTimer = time.AfterFunc(time.Until(until), w.Wake)
+		return
+	}
+	w.throttleTimer.Reset(time.Until(until))
+}
+
+func (w *Worker) claim(ctx context.Context, exclude []string) (*Job, error) {
+	if len(w.cfg.Queues) == 0 {
+		return w.claimer.Claim(ctx, ClaimFilter{ExcludeTypes: exclude})
+	}
+
+	for _, queue := range weightedOrder(w.cfg.Queues, rand.IntN) {
+		job, err := w.claimer.Claim(ctx, ClaimFilter{ExcludeTypes: exclude, Queues: []string{queue}})
+		if err != nil || job != nil {
+			return job, err
+		}
+	}
+	return nil, nil
+}
+
+// weightedOrder returns the queue names in a random order where each remaining
+// queue is picked next with probability proportional to its weight.
+// intN returns a uniform integer in [0, n).
+func weightedOrder(weights map[string]int, intN func(n int) int) []string {
+	names := make([]string, 0, len(weights))
+	total := 0
+	for name, weight := range weights {
+		if weight > 0 {
+			names = append(names, name)
+			total += weight
+		}
+	}
+	sort.Strings(names)
+
+	out := make([]string, 0, len(names))
+	for len(names) > 0 {
+		pick := intN(total)
+		for i, name := range names {
+			if pick < weights[name] {
+				out = append(out, name)
+				total -= weights[name]
+				names = append(names[:i], names[i+1:]...)
+				break
+			}
+			pick -= weights[name]
+		}
+	}
+	return out
+}
+
+func (w *Worker) execute(ctx context.Context, job *Job) {
+	ctx, span := startJobSpan(ctx, job)
+	defer span.End()
+
+	runCtx, cancelRun := context.WithCancelCause(ctx)
+	defer cancelRun(nil)
+
+	heartbeatDone := make(chan struct{})
+	go func() {
+		defer close(heartbeatDone)
+		w.heartbeat(runCtx, job, cancelRun)
+	}()
+
+	runErr := w.registry.Run(runCtx, job)
+	lockLost := errors.Is(context.Cause(runCtx), ErrLockLost)
+	cancelRun(nil)
+	<-heartbeatDone
+
+	if runErr != nil {
+		span.RecordError(runErr)
+		span.SetStatus(codes.Error, runErr.Error())
+	}
+
+	if lockLost {
+		span.SetAttributes(attribute.Bool("job.lock_lost", true))
+		// Another worker owns the job now; its outcome is theirs to record.
+		slog.Warn("job lock lost; discarding result", "job_id", job.ID, "job_type", job.Type)
+		return
+	}
+
+	// Record the outcome even when ctx was cancelled by a forced shutdown.
+	storeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+	defer cancel()
+
+	var err error
+	if runErr != nil {
+		err = w.claimer.Fail(storeCtx, FailureInput{
+			JobID:       job.ID,
+			Attempts:    job.Attempts,
+			MaxAttempts: w.registry.MaxAttempts(job),
+			RetryDelay:  w.registry.RetryDelay(job),
+			Err:         runErr,
+		})
+	} else {
+		err = w.claimer.Complete(storeCtx, job.ID, job.Attempts)
+	}
+
+	if errors.Is(err, ErrLockLost) {
+		slog.Warn("job lock lost before its result was recorded", "job_id", job.ID, "job_type", job.Type)
+		return
+	}
+	if err != nil {
+		w.cfg.OnError(err)
+	}
+}
+
+// heartbeat extends the job's lock until ctx is done, cancelling ctx with
+// ErrLockLost if the lock turns out to belong to someone else.
+func (w *Worker) heartbeat(ctx context.Context, job *Job, cancel context.CancelCauseFunc) {
+	ticker := time.NewTicker(w.cfg.HeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := w.claimer.Extend(ctx, job.ID, job.Attempts)
+		if errors.Is(err, ErrLockLost) {
+			cancel(ErrLockLost)
+			return
+		}
+		if err != nil && ctx.Err() == nil {
+			// Transient failures are retried on the next tick; the lease
+			// has LockTTL of slack before the reaper takes the job back.
+			w.cfg.OnError(err)
+		}
+	}
+}
+
+func (w *Worker) reap(ctx context.Context) {
+	reclaimed, err := w.claimer.ReclaimExpired(ctx, 100, w.registry.MaxAttempts)
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			w.cfg.OnError(err)
+		}
+		return
+	}
+	if reclaimed > 0 {
+		slog.Warn("reclaimed jobs with expired locks", "count", reclaimed)
+	}
+}
+
+func (w *Worker) drain(wg *sync.WaitGroup, cancelJobs context.CancelFunc) {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	w.mu.Lock()
+	running := w.slots.running
+	w.mu.Unlock()
+	slog.Info("worker draining in-flight jobs", "running", running, "timeout", w.cfg.ShutdownTimeout.String())
+
+	select {
+	case <-done:
+	case <-time.After(w.cfg.ShutdownTimeout):
+		slog.Warn("worker shutdown timeout reached; cancelling in-flight jobs")
+		cancelJobs()
+		<-done
+	}
+}
+
+// slots tracks in-flight jobs against the overall and per-type limits.
+// It is not safe for concurrent use; Worker guards it with its mutex.
+type slots struct {
+	capacity int
+	limits   map[string]int
+	running  int
+	byType   map[string]int
+}
+
+func newSlots(capacity int, limits map[string]int) *slots {
+	return &slots{capacity: capacity, limits: limits, byType: map[string]int{}}
+}
+
+func (s *slots) full() bool {
+	return s.running >= s.capacity
+}
+
+// saturated returns the job types that are at their concurrency limit.
+func (s *slots) saturated() []string {
+	out := []string{}
+	for jobType, limit := range s.limits {
+		if s.byType[jobType] >= limit {
+			out = append(out, jobType)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func (s *slots) acquire(jobType string) {
+	s.running++
+	s.byType[jobType]++
+}
+
+func (s *slots) release(jobType string) {
+	s.running--
+	s.byType[jobType]--
+	if s.byType[jobType] <= 0 {
+		delete(s.byType, jobType)
+	}
+}
+
+// ParseLimits parses "name=N" pairs separated by commas, e.g. "send_email=8,export=1".
+func ParseLimits(raw string) (map[string]int, error) {
+	out := map[string]int{}
+	for _, pair := range strings.Split(raw, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		name, value, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid limit %q (expected name=N)", pair)
+		}
+
+		limit, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || limit <= 0 {
+			return nil, fmt.Errorf("invalid limit %q (expected a positive integer)", pair)
+		}
+		out[name] = limit
+	}
+	return out, nil
+}
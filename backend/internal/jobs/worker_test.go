@@ -0,0 +1,190 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSlots(t *testing.T) {
+	s := newSlots(3, map[string]int{"send_email": 2, "export": 1})
+
+	if s.full() {
+		t.Fatalf("expected empty pool not to be full")
+	}
+	if got := s.saturated(); len(got) != 0 {
+		t.Fatalf("expected no saturated types, got %v", got)
+	}
+
+	s.acquire("send_email")
+	s.acquire("send_email")
+	if got := s.saturated(); !reflect.DeepEqual(got, []string{"send_email"}) {
+		t.Fatalf("expected send_email saturated, got %v", got)
+	}
+
+	s.acquire("export")
+	if !s.full() {
+		t.Fatalf("expected pool to be full")
+	}
+	if got := s.saturated(); !reflect.DeepEqual(got, []string{"export", "send_email"}) {
+		t.Fatalf("expected both types saturated, got %v", got)
+	}
+
+	s.release("send_email")
+	if s.full() {
+		t.Fatalf("expected a free slot after release")
+	}
+	if got := s.saturated(); !reflect.DeepEqual(got, []string{"export"}) {
+		t.Fatalf("expected only export saturated, got %v", got)
+	}
+}
+
+func TestParseLimits(t *testing.T) {
+	t.Run("parses pairs", func(t *testing.T) {
+		got, err := ParseLimits(" send_email=8, export=1 ,")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := map[string]int{"send_email": 8, "export": 1}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	})
+
+	t.Run("empty input yields no limits", func(t *testing.T) {
+		got, err := ParseLimits("")
+		if err != nil || len(got) != 0 {
+			t.Fatalf("expected empty map, got %v (%v)", got, err)
+		}
+	})
+
+	t.Run("rejects malformed pairs", func(t *testing.T) {
+		for _, raw := range []string{"send_email", "=3", "send_email=0", "send_email=x"} {
+			if _, err := ParseLimits(raw); err == nil {
+				t.Fatalf("expected error for %q", raw)
+			}
+		}
+	})
+}
+
+func TestWeightedOrder(t *testing.T) {
+	weights := map[string]int{"critical": 6, "default": 3, "low": 1}
+
+	t.Run("picks by cumulative weight in name order", func(t *testing.T) {
+		// Names sort as critical, default, low; 7 falls in default's range [6, 9).
+		picks := []int{7, 0, 0}
+		got := weightedOrder(weights, func(n int) int {
+			pick := picks[0]
+			picks = picks[1:]
+			return pick
+		})
+		want := []string{"default", "critical", "low"}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	})
+
+	t.Run("ignores non-positive weights", func(t *testing.T) {
+		got := weightedOrder(map[string]int{"a": 1, "b": 0}, func(n int) int { return 0 })
+		if len(got) != 1 || got[0] != "a" {
+			t.Fatalf("expected [a], got %v", got)
+		}
+	})
+
+	t.Run("first pick follows the weights", func(t *testing.T) {
+		first := map[string]int{}
+		for pick := 0; pick < 10; pick++ {
+			next := pick
+			order := weightedOrder(weights, func(n int) int {
+				value := next
+				next = 0
+				return value
+			})
+			first[order[0]]++
+		}
+		if first["critical"] != 6 || first["default"] != 3 || first["low"] != 1 {
+			t.Fatalf("unexpected distribution %v", first)
+		}
+	})
+}
+
+func TestThrottles(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	th := newThrottles()
+
+	if got := th.active(now); len(got) != 0 {
+		t.Fatalf("expected nothing throttled, got %v", got)
+	}
+	if !th.next(now).IsZero() {
+		t.Fatalf("expected no next expiry")
+	}
+
+	th.set("send_email", now.Add(500*time.Millisecond))
+	th.set("export", now.Add(2*time.Second))
+
+	if got := th.active(now); !reflect.DeepEqual(got, []string{"export", "send_email"}) {
+		t.Fatalf("unexpected throttled types %v", got)
+	}
+	if got := th.next(now); !got.Equal(now.Add(500 * time.Millisecond)) {
+		t.Fatalf("expected earliest expiry of send_email, got %s", got)
+	}
+
+	later := now.Add(time.Second)
+	if got := th.active(later); !reflect.DeepEqual(got, []string{"export"}) {
+		t.Fatalf("expected expired throttle to be dropped, got %v", got)
+	}
+	if got := th.next(later); !got.Equal(now.Add(2 * time.Second)) {
+		t.Fatalf("unexpected next expiry %s", got)
+	}
+}
+
+func TestParseRateLimits(t *testing.T) {
+	got, err := ParseRateLimits(" send_email=10/1s, export=1/1m ,")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]RateLimit{
+		"send_email": {Limit: 10, Per: time.Second},
+		"export":     {Limit: 1, Per: time.Minute},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	for _, raw := range []string{"send_email", "send_email=10", "send_email=0/1s", "send_email=10/soon", "=1/1s", "a=1/0s"} {
+		if _, err := ParseRateLimits(raw); err == nil {
+			t.Fatalf("expected error for %q", raw)
+		}
+	}
+}
+
+type failingLimiter struct{}
+
+func (failingLimiter) Allow(context.Context, string, int, time.Duration) (bool, time.Duration, error) {
+	return false, 0, errors.New("redis down")
+}
+
+func TestAllowLimiterErrors(t *testing.T) {
+	limits := map[string]RateLimit{"send_email": {Limit: 1, Per: time.Second}}
+
+	closed := NewClaimer(nil, ClaimerConfig{RateLimiter: failingLimiter{}, RateLimits: limits})
+	if closed.allow(context.Background(), "send_email") {
+		t.Fatalf("expected a limiter error to hold the job back")
+	}
+	if got := closed.throttles.active(time.Now()); !reflect.DeepEqual(got, []string{"send_email"}) {
+		t.Fatalf("expected send_email to be throttled, got %v", got)
+	}
+	if !closed.allow(context.Background(), "export") {
+		t.Fatalf("expected types without a rate limit to be allowed")
+	}
+
+	open := NewClaimer(nil, ClaimerConfig{RateLimiter: failingLimiter{}, RateLimits: limits, RateLimitFailOpen: true})
+	if !open.allow(context.Background(), "send_email") {
+		t.Fatalf("expected a fail-open claimer to allow the job")
+	}
+	if got := open.throttles.active(time.Now()); len(got) != 0 {
+		t.Fatalf("expected nothing throttled, got %v", got)
+	}
+}
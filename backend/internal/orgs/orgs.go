@@ -13,6 +13,7 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"saas-core-template/backend/internal/audit"
+	"saas-core-template/backend/internal/email"
 	"saas-core-template/backend/internal/jobs"
 )
 
@@ -26,7 +27,7 @@ var (
 
 type Service struct {
 	db    *pgxpool.Pool
-	jobs  jobs.Enqueuer
+	jobs  jobs.TxEnqueuer
 	audit audit.Recorder
 }
 
@@ -65,6 +66,9 @@ type CreateInviteInput struct {
 	InvitedByUserID string
 	Email           string
 	Role            string
+	// AcceptURL is the invite acceptance page; the invite token is appended as ?token=.
+	// The invite email is only sent when it is set.
+	AcceptURL string
 }
 
 type AcceptInviteInput struct {
@@ -94,7 +98,7 @@ func NewService(db *pgxpool.Pool, opts ...func(*Service)) *Service {
 	return s
 }
 
-func WithJobs(enqueuer jobs.Enqueuer) func(*Service) {
+func WithJobs(enqueuer jobs.TxEnqueuer) func(*Service) {
 	return func(s *Service) {
 		s.jobs = enqueuer
 	}
@@ -230,8 +234,8 @@ func (s *Service) ListMembers(ctx context.Context, organizationID string) ([]Mem
 }
 
 func (s *Service) CreateInvite(ctx context.Context, input CreateInviteInput) (Invite, error) {
-	email := normalizeEmail(input.Email)
-	if email == "" {
+	address := normalizeEmail(input.Email)
+	if address == "" {
 		return Invite{}, fmt.Errorf("missing email")
 	}
 
@@ -243,8 +247,20 @@ func (s *Service) CreateInvite(ctx context.Context, input CreateInviteInput) (In
 		return Invite{}, fmt.Errorf("invalid role")
 	}
 
+	token, err := newToken(16)
+	if err != nil {
+		return Invite{}, fmt.Errorf("generate token: %w", err)
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return Invite{}, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var orgName string
 	var orgKind string
-	if err := s.db.QueryRow(ctx, `SELECT kind FROM organizations WHERE id = $1`, input.OrganizationID).Scan(&orgKind); err != nil {
+	if err := tx.QueryRow(ctx, `SELECT name, kind FROM organizations WHERE id = $1`, input.OrganizationID).Scan(&orgName, &orgKind); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return Invite{}, ErrNotFound
 		}
@@ -254,17 +270,12 @@ func (s *Service) CreateInvite(ctx context.Context, input CreateInviteInput) (In
 		return Invite{}, ErrInvalidOrganization
 	}
 
-	token, err := newToken(16)
-	if err != nil {
-		return Invite{}, fmt.Errorf("generate token: %w", err)
-	}
-
 	var invite Invite
-	err = s.db.QueryRow(ctx, `
+	err = tx.QueryRow(ctx, `
 		INSERT INTO organization_invites (organization_id, email, role, token, invited_by_user_id)
 		VALUES ($1, $2, $3, $4, $5)
 		RETURNING id::text, organization_id::text, email, role, token, created_at, accepted_at
-	`, input.OrganizationID, email, role, token, input.InvitedByUserID).Scan(
+	`, input.OrganizationID, address, role, token, input.InvitedByUserID).Scan(
 		&invite.ID,
 		&invite.OrganizationID,
 		&invite.Email,
@@ -280,17 +291,31 @@ func (s *Service) CreateInvite(ctx context.Context, input CreateInviteInput) (In
 		return Invite{}, fmt.Errorf("insert invite: %w", err)
 	}
 
+	// The email is enqueued on the same transaction so an invite never exists without one.
+	if err := s.enqueueInviteEmail(ctx, tx, invite, orgName, input.AcceptURL); err != nil {
+		return Invite{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return Invite{}, fmt.Errorf("commit create invite: %w", err)
+	}
+
 	_ = s.audit.Record(ctx, audit.Event{
 		OrganizationID: input.OrganizationID,
 		UserID:         input.InvitedByUserID,
 		Action:         "organization_invite_created",
-		Data:           map[string]any{"email": email, "role": role},
+		Data:           map[string]any{"email": address, "role": role},
 	})
 
 	return invite, nil
 }
 
-func (s *Service) EnqueueInviteEmail(ctx context.Context, invite Invite, acceptURL string) error {
+// InviteAcceptURL builds the link sent in invite emails.
+func InviteAcceptURL(acceptURL string, token string) string {
+	return strings.TrimSpace(acceptURL) + "?token=" + token
+}
+
+func (s *Service) enqueueInviteEmail(ctx context.Context, tx pgx.Tx, invite Invite, orgName string, acceptURL string) error {
 	if s.jobs == nil {
 		return nil
 	}
@@ -298,17 +323,20 @@ func (s *Service) EnqueueInviteEmail(ctx context.Context, invite Invite, acceptU
 		return nil
 	}
 
-	orgName := "your workspace"
-	_ = s.db.QueryRow(ctx, `SELECT name FROM organizations WHERE id = $1`, invite.OrganizationID).Scan(&orgName)
+	if strings.TrimSpace(orgName) == "" {
+		orgName = "your workspace"
+	}
 
+	link := InviteAcceptURL(acceptURL, invite.Token)
 	subject := fmt.Sprintf("You're invited to join %s", orgName)
-	text := fmt.Sprintf("You have been invited to join %s.\n\nAccept: %s\n", orgName, acceptURL)
-
-	_, err := s.jobs.Enqueue(ctx, "send_email", map[string]any{
-		"to":      invite.Email,
-		"subject": subject,
-		"text":    text,
-	}, time.Now().UTC())
+	text := fmt.Sprintf("You have been invited to join %s.\n\nAccept: %s\n", orgName, link)
+
+	_, err := s.jobs.EnqueueTx(ctx, tx, email.SendJobType, email.SendJob{
+		Kind:    "org_invite",
+		To:      invite.Email,
+		Subject: subject,
+		Text:    text,
+	}, time.Now().UTC(), jobs.WithUniqueKey("org_invite_email:"+invite.ID, jobs.OnConflictSkip), jobs.WithPriority(jobs.PriorityHigh))
 	if err != nil {
 		return fmt.Errorf("enqueue invite email: %w", err)
 	}
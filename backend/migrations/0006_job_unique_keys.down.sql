@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS uq_jobs_active_unique_key;
+
+ALTER TABLE jobs
+  DROP COLUMN IF EXISTS unique_key;
@@ -0,0 +1,9 @@
+-- Optional idempotency key for jobs.
+
+ALTER TABLE jobs
+  ADD COLUMN IF NOT EXISTS unique_key TEXT;
+
+-- At most one queued or processing job per key; finished jobs do not block new ones.
+CREATE UNIQUE INDEX IF NOT EXISTS uq_jobs_active_unique_key
+ON jobs(unique_key)
+WHERE unique_key IS NOT NULL AND status IN ('queued', 'processing');
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_job_schedules_next_run_at;
+DROP TABLE IF EXISTS job_schedules;
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS uq_job_attempts_running;
+DROP INDEX IF EXISTS idx_job_attempts_job_started_at;
+
+ALTER TABLE job_attempts
+  DROP CONSTRAINT IF EXISTS job_attempts_outcome_check;
+
+DROP TABLE IF EXISTS job_attempts;
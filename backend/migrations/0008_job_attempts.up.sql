@@ -0,0 +1,23 @@
+-- One row per job attempt, so retries keep their full failure history.
+
+CREATE TABLE IF NOT EXISTS job_attempts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    job_id UUID NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+    attempt INTEGER NOT NULL,
+    worker_id TEXT NOT NULL,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    finished_at TIMESTAMPTZ,
+    duration_ms BIGINT,
+    outcome TEXT NOT NULL DEFAULT 'running',
+    error TEXT
+);
+
+ALTER TABLE job_attempts
+  ADD CONSTRAINT job_attempts_outcome_check CHECK (outcome IN ('running', 'succeeded', 'failed', 'lock_expired'));
+
+CREATE INDEX IF NOT EXISTS idx_job_attempts_job_started_at ON job_attempts(job_id, started_at);
+
+-- At most one open attempt per job.
+CREATE UNIQUE INDEX IF NOT EXISTS uq_job_attempts_running
+ON job_attempts(job_id)
+WHERE finished_at IS NULL;
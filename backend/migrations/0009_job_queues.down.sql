@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_jobs_queued_priority;
+DROP INDEX IF EXISTS idx_jobs_queued_claim_order;
+
+ALTER TABLE jobs
+  DROP COLUMN IF EXISTS priority,
+  DROP COLUMN IF EXISTS queue;
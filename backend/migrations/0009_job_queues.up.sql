@@ -0,0 +1,14 @@
+-- Named queues and priorities for jobs.
+
+ALTER TABLE jobs
+  ADD COLUMN IF NOT EXISTS queue TEXT NOT NULL DEFAULT 'default',
+  ADD COLUMN IF NOT EXISTS priority INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_jobs_queued_claim_order
+ON jobs(queue, priority DESC, run_at, created_at)
+WHERE status = 'queued';
+
+-- Workers without JOBS_QUEUES claim across all queues in priority order.
+CREATE INDEX IF NOT EXISTS idx_jobs_queued_priority
+ON jobs(priority DESC, run_at, created_at)
+WHERE status = 'queued';
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_jobs_finished_updated_at;
+DROP INDEX IF EXISTS idx_jobs_archive_type_updated_at;
+
+DROP TABLE IF EXISTS jobs_archive;
@@ -0,0 +1,26 @@
+-- Finished jobs moved out of the hot jobs table by the retention job.
+
+CREATE TABLE IF NOT EXISTS jobs_archive (
+    id UUID PRIMARY KEY,
+    type TEXT NOT NULL,
+    queue TEXT NOT NULL,
+    priority INTEGER NOT NULL,
+    payload JSONB NOT NULL,
+    status TEXT NOT NULL,
+    run_at TIMESTAMPTZ NOT NULL,
+    attempts INTEGER NOT NULL,
+    max_attempts INTEGER NOT NULL,
+    last_error TEXT,
+    unique_key TEXT,
+    attempt_history JSONB NOT NULL DEFAULT '[]'::jsonb,
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    archived_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_jobs_archive_type_updated_at ON jobs_archive(type, updated_at DESC);
+
+-- Lets the retention job find expired finished jobs without scanning the queue.
+CREATE INDEX IF NOT EXISTS idx_jobs_finished_updated_at
+ON jobs(status, updated_at)
+WHERE status IN ('done', 'failed', 'cancelled');
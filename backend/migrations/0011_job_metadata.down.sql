@@ -0,0 +1,5 @@
+ALTER TABLE jobs_archive
+  DROP COLUMN IF EXISTS metadata;
+
+ALTER TABLE jobs
+  DROP COLUMN IF EXISTS metadata;
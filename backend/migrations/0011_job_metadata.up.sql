@@ -0,0 +1,7 @@
+-- Enqueue-time context for jobs (e.g. W3C trace context).
+
+ALTER TABLE jobs
+  ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}'::jsonb;
+
+ALTER TABLE jobs_archive
+  ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}'::jsonb;
@@ -0,0 +1,12 @@
+ALTER TABLE jobs_archive
+  DROP COLUMN IF EXISTS batch_id;
+
+DROP INDEX IF EXISTS idx_jobs_batch_id;
+
+ALTER TABLE jobs
+  DROP COLUMN IF EXISTS batch_id;
+
+ALTER TABLE job_batches
+  DROP CONSTRAINT IF EXISTS job_batches_counts_check;
+
+DROP TABLE IF EXISTS job_batches;
@@ -0,0 +1,30 @@
+-- Batches group jobs, track their progress and enqueue a callback job when all finish.
+
+CREATE TABLE IF NOT EXISTS job_batches (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    description TEXT NOT NULL DEFAULT '',
+    total INTEGER NOT NULL DEFAULT 0,
+    pending INTEGER NOT NULL DEFAULT 0,
+    succeeded INTEGER NOT NULL DEFAULT 0,
+    failed INTEGER NOT NULL DEFAULT 0,
+    cancelled INTEGER NOT NULL DEFAULT 0,
+    callback_type TEXT,
+    callback_payload JSONB,
+    callback_queue TEXT NOT NULL DEFAULT 'default',
+    callback_priority INTEGER NOT NULL DEFAULT 0,
+    callback_job_id UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    finished_at TIMESTAMPTZ
+);
+
+ALTER TABLE job_batches
+  ADD CONSTRAINT job_batches_counts_check CHECK (pending >= 0 AND pending + succeeded + failed + cancelled = total);
+
+ALTER TABLE jobs
+  ADD COLUMN IF NOT EXISTS batch_id UUID REFERENCES job_batches(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_jobs_batch_id ON jobs(batch_id) WHERE batch_id IS NOT NULL;
+
+ALTER TABLE jobs_archive
+  ADD COLUMN IF NOT EXISTS batch_id UUID;
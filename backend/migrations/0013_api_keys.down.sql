@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_api_keys_org_id;
+
+ALTER TABLE api_keys
+  DROP CONSTRAINT IF EXISTS api_keys_scopes_check;
+
+DROP TABLE IF EXISTS api_keys;
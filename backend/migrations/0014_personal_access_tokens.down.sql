@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_personal_access_tokens_user_id;
+
+ALTER TABLE personal_access_tokens
+  DROP CONSTRAINT IF EXISTS personal_access_tokens_scopes_check;
+
+DROP TABLE IF EXISTS personal_access_tokens;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS auth_identity_unlinks;
+DROP INDEX IF EXISTS idx_auth_identities_user_id;
+DROP INDEX IF EXISTS idx_auth_identities_verified_email;
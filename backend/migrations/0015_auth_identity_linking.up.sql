@@ -0,0 +1,16 @@
+-- Supports linking identities from different providers by verified email.
+
+CREATE INDEX IF NOT EXISTS idx_auth_identities_verified_email
+ON auth_identities(lower(provider_email))
+WHERE email_verified_at IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_auth_identities_user_id ON auth_identities(user_id);
+
+-- Records which providers a user has unlinked, so the next sign-in through that
+-- provider is not linked back to the user by verified email.
+CREATE TABLE IF NOT EXISTS auth_identity_unlinks (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, provider)
+);